@@ -0,0 +1,343 @@
+package a11y
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mathieu-keller/epub-parser/model"
+	"golang.org/x/net/html"
+)
+
+type Status string
+
+const (
+	Pass          Status = "pass"
+	Fail          Status = "fail"
+	Warning       Status = "warning"
+	NotApplicable Status = "not-applicable"
+)
+
+type Conformance struct {
+	Claimed       bool   `json:"claimed"`
+	Specification string `json:"specification,omitempty"`
+	WCAGVersion   string `json:"wcagVersion,omitempty"`
+	WCAGLevel     string `json:"wcagLevel,omitempty"`
+	Statement     string `json:"statement,omitempty"`
+}
+
+type Check struct {
+	Name    string   `json:"name"`
+	Status  Status   `json:"status"`
+	Message string   `json:"message"`
+	Files   []string `json:"files,omitempty"`
+}
+
+type Report struct {
+	Title               string      `json:"title"`
+	Conformance         Conformance `json:"conformance"`
+	CertifiedBy         string      `json:"certifiedBy,omitempty"`
+	CertifierCredential string      `json:"certifierCredential,omitempty"`
+	CertifierReport     string      `json:"certifierReport,omitempty"`
+	Summary             string      `json:"summary,omitempty"`
+	AccessModes         []string    `json:"accessModes,omitempty"`
+	Features            []string    `json:"features,omitempty"`
+	Hazards             []string    `json:"hazards,omitempty"`
+	Checks              []Check     `json:"checks"`
+}
+
+const epubA11y10 = "http://www.idpf.org/epub/a11y/accessibility-20170105.html#wcag-"
+
+var epubA11y11 = regexp.MustCompile(`^EPUB Accessibility (\d\.\d) - WCAG (\d\.\d) Level (A{1,3})$`)
+
+// NewReport summarizes the accessibility claims of a book and cross-checks
+// them against its content.
+func NewReport(book *model.Book) (*Report, error) {
+	accessibility := book.Metadata.Accessibility
+	if accessibility == nil {
+		accessibility = &model.Accessibility{}
+	}
+	report := &Report{
+		CertifiedBy:         accessibility.CertifiedBy,
+		CertifierCredential: accessibility.CertifierCredential,
+		CertifierReport:     accessibility.CertifierReport,
+		Summary:             accessibility.Summary,
+		AccessModes:         accessibility.AccessModes,
+		Features:            accessibility.Features,
+		Hazards:             accessibility.Hazards,
+	}
+	if book.Metadata.Titles != nil && len(*book.Metadata.Titles) > 0 {
+		report.Title = (*book.Metadata.Titles)[0].Title
+	}
+	report.Conformance = getConformance(accessibility.ConformsTo)
+
+	documents, err := readDocuments(book)
+	if err != nil {
+		return nil, err
+	}
+	report.Checks = []Check{
+		checkDiscoveryMetadata(accessibility),
+		checkInvalidValues(accessibility),
+		checkLanguage(book, documents),
+		checkNavigation(book),
+	}
+	pageList, err := checkPageList(book, accessibility)
+	if err != nil {
+		return nil, err
+	}
+	report.Checks = append(report.Checks, pageList, checkAltText(accessibility, documents))
+	return report, nil
+}
+
+// Passed reports whether no check failed.
+func (report *Report) Passed() bool {
+	for _, check := range report.Checks {
+		if check.Status == Fail {
+			return false
+		}
+	}
+	return true
+}
+
+func (report *Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(report, "", "  ")
+}
+
+func (report *Report) String() string {
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("Accessibility report for %q\n", report.Title))
+	if report.Conformance.Claimed {
+		builder.WriteString(fmt.Sprintf("Claims conformance to %s (WCAG %s Level %s)\n",
+			report.Conformance.Specification, report.Conformance.WCAGVersion, report.Conformance.WCAGLevel))
+	} else if report.Conformance.Statement != "" {
+		builder.WriteString(fmt.Sprintf("Claims conformance to %q (not a known EPUB Accessibility statement)\n", report.Conformance.Statement))
+	} else {
+		builder.WriteString("Does not claim conformance to EPUB Accessibility\n")
+	}
+	if report.CertifiedBy != "" {
+		builder.WriteString(fmt.Sprintf("Certified by %s", report.CertifiedBy))
+		if report.CertifierCredential != "" {
+			builder.WriteString(fmt.Sprintf(" (%s)", report.CertifierCredential))
+		}
+		builder.WriteString("\n")
+	}
+	if report.Summary != "" {
+		builder.WriteString(fmt.Sprintf("Summary: %s\n", report.Summary))
+	}
+	writeList(&builder, "Access modes", report.AccessModes)
+	writeList(&builder, "Features", report.Features)
+	writeList(&builder, "Hazards", report.Hazards)
+	builder.WriteString("Checks:\n")
+	for _, check := range report.Checks {
+		builder.WriteString(fmt.Sprintf("  [%s] %s: %s\n", check.Status, check.Name, check.Message))
+		for _, file := range check.Files {
+			builder.WriteString(fmt.Sprintf("      %s\n", file))
+		}
+	}
+	return builder.String()
+}
+
+func writeList(builder *strings.Builder, name string, values []string) {
+	if len(values) > 0 {
+		builder.WriteString(fmt.Sprintf("%s: %s\n", name, strings.Join(values, ", ")))
+	}
+}
+
+func getConformance(conformsTo []string) Conformance {
+	conformance := Conformance{}
+	for _, statement := range conformsTo {
+		statement = strings.TrimSpace(statement)
+		if conformance.Statement == "" {
+			conformance.Statement = statement
+		}
+		if strings.HasPrefix(statement, epubA11y10) {
+			level := strings.ToUpper(strings.TrimPrefix(statement, epubA11y10))
+			if level == "A" || level == "AA" || level == "AAA" {
+				return Conformance{
+					Claimed:       true,
+					Specification: "EPUB Accessibility 1.0",
+					WCAGVersion:   "2.0",
+					WCAGLevel:     level,
+					Statement:     statement,
+				}
+			}
+		}
+		if match := epubA11y11.FindStringSubmatch(statement); match != nil {
+			return Conformance{
+				Claimed:       true,
+				Specification: "EPUB Accessibility " + match[1],
+				WCAGVersion:   match[2],
+				WCAGLevel:     match[3],
+				Statement:     statement,
+			}
+		}
+	}
+	return conformance
+}
+
+func checkDiscoveryMetadata(accessibility *model.Accessibility) Check {
+	var missing []string
+	if len(accessibility.AccessModes) == 0 {
+		missing = append(missing, "schema:accessMode")
+	}
+	if len(accessibility.AccessModesSufficient) == 0 {
+		missing = append(missing, "schema:accessModeSufficient")
+	}
+	if len(accessibility.Features) == 0 {
+		missing = append(missing, "schema:accessibilityFeature")
+	}
+	if len(accessibility.Hazards) == 0 {
+		missing = append(missing, "schema:accessibilityHazard")
+	}
+	if accessibility.Summary == "" {
+		missing = append(missing, "schema:accessibilitySummary")
+	}
+	if len(missing) > 0 {
+		return Check{Name: "discovery-metadata", Status: Warning, Message: "missing " + strings.Join(missing, ", ")}
+	}
+	return Check{Name: "discovery-metadata", Status: Pass, Message: "all discovery metadata is declared"}
+}
+
+func checkInvalidValues(accessibility *model.Accessibility) Check {
+	if len(accessibility.InvalidValues) == 0 {
+		return Check{Name: "vocabulary", Status: Pass, Message: "all values are part of the schema.org vocabularies"}
+	}
+	values := make([]string, len(accessibility.InvalidValues))
+	for i, value := range accessibility.InvalidValues {
+		values[i] = fmt.Sprintf("%s=%q", value.Property, value.Value)
+	}
+	return Check{Name: "vocabulary", Status: Warning, Message: "unknown values " + strings.Join(values, ", ")}
+}
+
+func checkLanguage(book *model.Book, documents []document) Check {
+	declared := false
+	if book.Metadata.Languages != nil {
+		for _, language := range *book.Metadata.Languages {
+			if language.Valid {
+				declared = true
+			}
+		}
+	}
+	if !declared {
+		return Check{Name: "language", Status: Fail, Message: "the package does not declare a valid dc:language"}
+	}
+	var files []string
+	for _, document := range documents {
+		if !hasLanguage(document.root) {
+			files = append(files, document.item.Href)
+		}
+	}
+	if len(files) > 0 {
+		return Check{Name: "language", Status: Warning, Message: "content documents without a lang attribute", Files: files}
+	}
+	return Check{Name: "language", Status: Pass, Message: "the language of the publication and its content documents is declared"}
+}
+
+func checkNavigation(book *model.Book) Check {
+	if _, ok := book.ManifestItemByProperty("nav"); ok {
+		return Check{Name: "navigation", Status: Pass, Message: "a navigation document is present"}
+	}
+	if _, ok := book.NCXItem(); ok {
+		if strings.HasPrefix(book.Version, "2") {
+			return Check{Name: "navigation", Status: Pass, Message: "an NCX table of contents is present"}
+		}
+		return Check{Name: "navigation", Status: Fail, Message: "only an NCX is present, EPUB 3 requires a navigation document"}
+	}
+	return Check{Name: "navigation", Status: Fail, Message: "no navigation document is present"}
+}
+
+func checkPageList(book *model.Book, accessibility *model.Accessibility) (Check, error) {
+	claimed := accessibility.HasFeature("printPageNumbers") ||
+		accessibility.HasFeature("pageNavigation") ||
+		accessibility.HasFeature("pageBreakMarkers")
+	if !claimed {
+		return Check{Name: "page-list", Status: NotApplicable, Message: "page navigation is not claimed"}, nil
+	}
+	found := false
+	if nav, ok := book.ManifestItemByProperty("nav"); ok {
+		root, err := book.ReadItemHTML(nav)
+		if err != nil {
+			return Check{}, err
+		}
+		found = model.FindElement(root, func(node *html.Node) bool {
+			return node.Data == "nav" && model.HasToken(model.Attribute(node, "epub:type"), "page-list")
+		}) != nil
+	} else if ncx, ok := book.NCXItem(); ok {
+		root, err := book.ReadItemHTML(ncx)
+		if err != nil {
+			return Check{}, err
+		}
+		found = model.FindElement(root, func(node *html.Node) bool {
+			return strings.EqualFold(node.Data, "pagelist")
+		}) != nil
+	}
+	if !found {
+		return Check{Name: "page-list", Status: Fail, Message: "page navigation is claimed but there is no page list"}, nil
+	}
+	return Check{Name: "page-list", Status: Pass, Message: "a page list is present"}, nil
+}
+
+func checkAltText(accessibility *model.Accessibility, documents []document) Check {
+	images := 0
+	var files []string
+	for _, document := range documents {
+		missing := false
+		model.FindElement(document.root, func(node *html.Node) bool {
+			if node.Data == "img" {
+				images++
+				if _, ok := model.AttributeValue(node, "alt"); !ok {
+					missing = true
+				}
+			}
+			return false
+		})
+		if missing {
+			files = append(files, document.item.Href)
+		}
+	}
+	switch {
+	case images == 0:
+		return Check{Name: "alt-text", Status: NotApplicable, Message: "the content documents contain no images"}
+	case len(files) == 0:
+		return Check{Name: "alt-text", Status: Pass, Message: fmt.Sprintf("all %d images have alternative text", images)}
+	case accessibility.HasFeature("alternativeText"):
+		return Check{Name: "alt-text", Status: Fail, Message: "alternativeText is claimed but images without alt attribute were found", Files: files}
+	default:
+		return Check{Name: "alt-text", Status: Fail, Message: "images without alt attribute were found", Files: files}
+	}
+}
+
+type document struct {
+	item model.ManifestItem
+	root *html.Node
+}
+
+func readDocuments(book *model.Book) ([]document, error) {
+	var documents []document
+	for _, item := range book.SpineDocuments() {
+		if !item.IsXHTML() {
+			continue
+		}
+		root, err := book.ReadItemHTML(item)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document{item: item, root: root})
+	}
+	return documents, nil
+}
+
+func hasLanguage(root *html.Node) bool {
+	element := model.FindElement(root, func(node *html.Node) bool {
+		return node.Data == "html"
+	})
+	if element == nil {
+		return false
+	}
+	lang := model.Attribute(element, "lang")
+	if lang == "" {
+		lang = model.Attribute(element, "xml:lang")
+	}
+	return lang != ""
+}
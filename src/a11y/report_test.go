@@ -0,0 +1,103 @@
+package a11y
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mathieu-keller/epub-parser/internal/epubtest"
+)
+
+const container = `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>`
+
+const opf = `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title>Accessible</dc:title>
+    <dc:language>en</dc:language>
+    <meta property="schema:accessMode">textual</meta>
+    <meta property="schema:accessModeSufficient">textual</meta>
+    <meta property="schema:accessibilityFeature">alternativeText</meta>
+    <meta property="schema:accessibilityFeature">printPageNumbers</meta>
+    <meta property="schema:accessibilityHazard">none</meta>
+    <meta property="schema:accessibilitySummary">Summary</meta>
+    <meta property="dcterms:conformsTo">EPUB Accessibility 1.1 - WCAG 2.1 Level AA</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="c1" href="chapter%201.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c2" href="chapter2.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="c1"/>
+    <itemref idref="c2"/>
+  </spine>
+</package>`
+
+const nav = `<?xml version="1.0" encoding="utf-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="en">
+<body><nav epub:type="toc"><ol><li><a href="chapter%201.xhtml">One</a></li></ol></nav></body>
+</html>`
+
+func Test_report(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"META-INF/container.xml": container,
+		"OEBPS/content.opf":      opf,
+		"OEBPS/nav.xhtml":        nav,
+		"OEBPS/chapter 1.xhtml":  `<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="en"><body><p>One</p><img src="a.png" alt=""/></body></html>`,
+		"OEBPS/chapter2.xhtml":   `<html xmlns="http://www.w3.org/1999/xhtml"><body><p>Two</p><img src="b.png"/></body></html>`,
+	})
+	report, err := NewReport(book)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Conformance.Claimed || report.Conformance.WCAGVersion != "2.1" || report.Conformance.WCAGLevel != "AA" {
+		t.Logf("unexpected conformance %+v", report.Conformance)
+		t.Fail()
+	}
+	expected := map[string]Status{
+		"discovery-metadata": Pass,
+		"vocabulary":         Pass,
+		"language":           Warning,
+		"navigation":         Pass,
+		"page-list":          Fail,
+		"alt-text":           Fail,
+	}
+	for _, check := range report.Checks {
+		if expected[check.Name] != check.Status {
+			t.Logf("check %s expected '%s' but is '%s' (%s)", check.Name, expected[check.Name], check.Status, check.Message)
+			t.Fail()
+		}
+	}
+	if report.Passed() {
+		t.Log("report should not pass")
+		t.Fail()
+	}
+	if !strings.Contains(report.String(), "Claims conformance to EPUB Accessibility 1.1 (WCAG 2.1 Level AA)") {
+		t.Log(report.String())
+		t.Fail()
+	}
+	data, err := report.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := Report{}
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Checks) != len(report.Checks) {
+		t.Logf("json round trip failed: %v", err)
+		t.Fail()
+	}
+}
+
+func Test_conformance_epub_a11y_1_0(t *testing.T) {
+	conformance := getConformance([]string{"http://www.idpf.org/epub/a11y/accessibility-20170105.html#wcag-aa"})
+	if conformance.Specification != "EPUB Accessibility 1.0" || conformance.WCAGLevel != "AA" {
+		t.Logf("unexpected conformance %+v", conformance)
+		t.Fail()
+	}
+}
@@ -0,0 +1,283 @@
+package builder
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mathieu-keller/epub-parser/epub_v3"
+	"github.com/mathieu-keller/epub-parser/model"
+	"github.com/mathieu-keller/epub-parser/opf"
+)
+
+const (
+	packagePath = "EPUB/package.opf"
+	navHref     = "nav.xhtml"
+	ncxHref     = "toc.ncx"
+)
+
+var mediaTypes = map[string]string{
+	".xhtml": "application/xhtml+xml",
+	".html":  "application/xhtml+xml",
+	".css":   "text/css",
+	".js":    "application/javascript",
+	".jpg":   "image/jpeg",
+	".jpeg":  "image/jpeg",
+	".png":   "image/png",
+	".gif":   "image/gif",
+	".svg":   "image/svg+xml",
+	".webp":  "image/webp",
+	".ttf":   "font/ttf",
+	".otf":   "font/otf",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+	".mp3":   "audio/mpeg",
+	".m4a":   "audio/mp4",
+	".mp4":   "video/mp4",
+	".smil":  "application/smil+xml",
+}
+
+// Builder creates an EPUB 3 book from scratch. Hrefs are relative to the
+// package document, which is written to EPUB/package.opf.
+type Builder struct {
+	Metadata model.Metadata
+	// TOC is the table of contents. If it is empty, it is made of the chapters
+	// added with a title.
+	TOC []model.TOCEntry
+	// TOCTitle is the heading of the navigation document.
+	TOCTitle string
+	// NCX adds an EPUB 2 NCX table of contents for older reading systems.
+	NCX bool
+	// Modified is written as dcterms:modified, the current time if zero.
+	Modified time.Time
+	items    []model.ManifestItem
+	data     map[string][]byte
+	spine    []model.SpineItem
+	chapters []model.TOCEntry
+}
+
+func New(metadata model.Metadata) *Builder {
+	return &Builder{
+		Metadata: metadata,
+		TOCTitle: "Table of Contents",
+		data:     map[string][]byte{},
+	}
+}
+
+// AddItem adds a resource to the manifest. An empty id is generated from the
+// href.
+func (builder *Builder) AddItem(item model.ManifestItem, data []byte) (model.ManifestItem, error) {
+	if item.Href == "" || item.MediaType == "" {
+		return item, errors.New("manifest item needs href and media type")
+	}
+	item.Href = path.Clean(item.Href)
+	if path.IsAbs(item.Href) || item.Href == ".." || strings.HasPrefix(item.Href, "../") {
+		return item, fmt.Errorf("href %s is outside of the package directory", item.Href)
+	}
+	if item.Href == navHref || (builder.NCX && item.Href == ncxHref) {
+		return item, fmt.Errorf("href %s is reserved", item.Href)
+	}
+	if _, ok := builder.data[item.Href]; ok {
+		return item, fmt.Errorf("href %s already added", item.Href)
+	}
+	if item.Id == "" {
+		item.Id = builder.newId(item.Href)
+	} else if builder.hasId(item.Id) {
+		return item, fmt.Errorf("id %s already added", item.Id)
+	}
+	builder.items = append(builder.items, item)
+	builder.data[item.Href] = data
+	return item, nil
+}
+
+// AddResource adds a resource with the media type of its file extension.
+func (builder *Builder) AddResource(href string, data []byte, properties ...string) (model.ManifestItem, error) {
+	mediaType, ok := mediaTypes[strings.ToLower(path.Ext(href))]
+	if !ok {
+		return model.ManifestItem{}, fmt.Errorf("unknown media type of %s", href)
+	}
+	return builder.AddItem(model.ManifestItem{Href: href, MediaType: mediaType, Properties: properties}, data)
+}
+
+// AddChapter adds an XHTML document to the end of the spine. Chapters with a
+// title are added to the table of contents.
+func (builder *Builder) AddChapter(href string, title string, data []byte) (model.ManifestItem, error) {
+	item, err := builder.AddItem(model.ManifestItem{Href: href, MediaType: "application/xhtml+xml"}, data)
+	if err != nil {
+		return item, err
+	}
+	builder.spine = append(builder.spine, model.SpineItem{IdRef: item.Id, Linear: true})
+	if title != "" {
+		builder.chapters = append(builder.chapters, model.TOCEntry{Title: title, Href: item.Href})
+	}
+	return item, nil
+}
+
+// AddSpineItem adds a manifest item to the end of the spine.
+func (builder *Builder) AddSpineItem(id string, linear bool) error {
+	if !builder.hasId(id) {
+		return fmt.Errorf("manifest item %q does not exist", id)
+	}
+	builder.spine = append(builder.spine, model.SpineItem{IdRef: id, Linear: linear})
+	return nil
+}
+
+// SetCover adds the cover image.
+func (builder *Builder) SetCover(href string, data []byte) (model.ManifestItem, error) {
+	return builder.AddResource(href, data, "cover-image")
+}
+
+// Build creates the book with its package document, navigation document and
+// NCX in memory. Write it with model.Book.WriteTo or SaveAs.
+func (builder *Builder) Build() (*model.Book, error) {
+	metadata := builder.Metadata
+	if len(model.Values(metadata.Titles)) == 0 {
+		return nil, errors.New("metadata has no title")
+	}
+	if len(model.Values(metadata.Languages)) == 0 {
+		return nil, errors.New("metadata has no language")
+	}
+	if len(builder.spine) == 0 {
+		return nil, errors.New("spine is empty")
+	}
+	if metadata.MainId.Id == "" {
+		if identifiers := model.Values(metadata.Identifiers); len(identifiers) > 0 {
+			metadata.MainId = identifiers[0]
+		} else {
+			id, err := newUUID()
+			if err != nil {
+				return nil, err
+			}
+			metadata.MainId = model.Identifier{Scheme: "urn", Id: "uuid:" + id}
+		}
+	}
+	toc := builder.TOC
+	if len(toc) == 0 {
+		toc = builder.chapters
+	}
+	language := model.Values(metadata.Languages)[0]
+	title := model.Values(metadata.Titles)[0].Title
+
+	book := &model.Book{
+		Version:   "3.0",
+		Metadata:  metadata,
+		Container: model.Container{Rootfile: model.Rootfile{Path: packagePath, Type: "application/oebps-package+xml"}},
+	}
+	book.WriteFile("mimetype", []byte("application/epub+zip"))
+	book.WriteFile("META-INF/container.xml", []byte(containerDocument))
+	for _, item := range builder.items {
+		book.WriteFile(path.Join(path.Dir(packagePath), item.Href), builder.data[item.Href])
+	}
+	book.WriteFile(path.Join(path.Dir(packagePath), navHref), NavDocument(title, rawLanguage(language), builder.TOCTitle, toc, nil))
+	if builder.NCX {
+		uid := metadata.MainId.Id
+		if metadata.MainId.Scheme != "" {
+			uid = metadata.MainId.Scheme + ":" + uid
+		}
+		book.WriteFile(path.Join(path.Dir(packagePath), ncxHref), NCXDocument(uid, title, toc))
+	}
+	book.WriteFile(packagePath, builder.packageDocument(rawLanguage(language)))
+
+	modified := builder.Modified
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+	data, err := epub_v3.WriteOpf(book, modified)
+	if err != nil {
+		return nil, err
+	}
+	book.WriteFile(packagePath, data)
+	err = epub_v3.ParseOpf(book)
+	if err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+// packageDocument returns the package document with an empty metadata
+// element, which is filled by epub_v3.WriteOpf.
+func (builder *Builder) packageDocument(language string) []byte {
+	document := strings.Builder{}
+	document.WriteString(`<?xml version="1.0" encoding="utf-8"?>` + "\n")
+	document.WriteString(`<package xmlns="` + opf.NamespaceOpf + `" version="3.0" unique-identifier="pub-id" xml:lang="` + escape(language) + `">` + "\n")
+	document.WriteString(`  <metadata xmlns:dc="` + opf.NamespaceDC + `">` + "\n  </metadata>\n  <manifest>\n")
+	document.WriteString("    " + opf.Markup("item", "", "id", "nav", "href", navHref, "media-type", "application/xhtml+xml", "properties", "nav") + "\n")
+	if builder.NCX {
+		document.WriteString("    " + opf.Markup("item", "", "id", "ncx", "href", ncxHref, "media-type", "application/x-dtbncx+xml") + "\n")
+	}
+	for _, item := range builder.items {
+		document.WriteString("    " + opf.Markup("item", "",
+			"id", item.Id,
+			"href", href(item.Href, ""),
+			"media-type", item.MediaType,
+			"fallback", item.Fallback,
+			"media-overlay", item.MediaOverlay,
+			"properties", strings.Join(item.Properties, " "),
+		) + "\n")
+	}
+	document.WriteString("  </manifest>\n")
+	if builder.NCX {
+		document.WriteString(`  <spine toc="ncx">` + "\n")
+	} else {
+		document.WriteString("  <spine>\n")
+	}
+	for _, itemref := range builder.spine {
+		linear := ""
+		if !itemref.Linear {
+			linear = "no"
+		}
+		document.WriteString("    " + opf.Markup("itemref", "", "idref", itemref.IdRef, "linear", linear) + "\n")
+	}
+	document.WriteString("  </spine>\n</package>\n")
+	return []byte(document.String())
+}
+
+func (builder *Builder) hasId(id string) bool {
+	if id == "nav" || id == "ncx" || id == "pub-id" {
+		return true
+	}
+	for _, item := range builder.items {
+		if item.Id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// newId derives an XML name from the file name of href.
+func (builder *Builder) newId(href string) string {
+	base := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, path.Base(href))
+	if base == "" || !(base[0] >= 'a' && base[0] <= 'z' || base[0] >= 'A' && base[0] <= 'Z' || base[0] == '_') {
+		base = "item_" + base
+	}
+	id := base
+	for i := 2; builder.hasId(id); i++ {
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+	return id
+}
+
+func newUUID() (string, error) {
+	uuid := make([]byte, 16)
+	if _, err := rand.Read(uuid); err != nil {
+		return "", err
+	}
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:]), nil
+}
+
+func rawLanguage(language model.Language) string {
+	if language.Raw != "" {
+		return language.Raw
+	}
+	return language.String()
+}
@@ -0,0 +1,156 @@
+package builder
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/mathieu-keller/epub-parser/internal/epubtest"
+	"github.com/mathieu-keller/epub-parser/model"
+)
+
+func chapter(title string) []byte {
+	return []byte(`<?xml version="1.0" encoding="utf-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title>` + title + `</title></head><body><h1 id="top">` + title + `</h1></body></html>`)
+}
+
+func Test_build(t *testing.T) {
+	builder := New(model.Metadata{
+		Titles:    &[]model.Title{{Title: "Built & Bound"}},
+		Languages: &[]model.Language{model.ParseLanguage("en")},
+		Creators:  &[]model.Creator{{Name: "Jane Doe", Role: "author"}},
+	})
+	builder.NCX = true
+	builder.Modified = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := builder.AddResource("css/style.css", []byte("p { margin: 0 }")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := builder.SetCover("images/cover.png", []byte{0x89, 'P', 'N', 'G'}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := builder.AddChapter("text/1.xhtml", "One", chapter("One")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := builder.AddChapter("text/2.xhtml", "Two", chapter("Two")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := builder.AddChapter("text/2.xhtml", "Again", chapter("Again")); err == nil {
+		t.Logf("expected error for duplicate href")
+		t.Fail()
+	}
+	built, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	book := epubtest.Reopen(t, built)
+	if book.Version != "3.0" || (*book.Metadata.Titles)[0].Title != "Built & Bound" {
+		t.Logf("unexpected version %s or title %v", book.Version, *book.Metadata.Titles)
+		t.Fail()
+	}
+	if book.Metadata.MainId.Scheme != "urn" || len(book.Metadata.MainId.Id) != len("uuid:")+36 {
+		t.Logf("expected generated uuid but got %v", book.Metadata.MainId)
+		t.Fail()
+	}
+	creator := (*book.Metadata.Creators)[0]
+	if creator.Name != "Jane Doe" || creator.RawRole != "aut" {
+		t.Logf("unexpected creator %v", creator)
+		t.Fail()
+	}
+	if len(*book.Spine) != 2 || (*book.Spine)[1].IdRef != "item_2_xhtml" {
+		t.Logf("unexpected spine %v", *book.Spine)
+		t.Fail()
+	}
+	if cover, ok := book.ManifestItemByProperty("cover-image"); !ok || cover.MediaType != "image/png" {
+		t.Logf("unexpected cover %v", cover)
+		t.Fail()
+	}
+	toc, err := book.ReadTOC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(toc) != 2 || toc[1].Title != "Two" || toc[1].Href != "text/2.xhtml" {
+		t.Logf("unexpected toc %v", toc)
+		t.Fail()
+	}
+	ncx, err := book.ReadFile("EPUB/toc.ncx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(ncx, []byte(`<content src="text/2.xhtml"/>`)) {
+		t.Logf("unexpected ncx %s", ncx)
+		t.Fail()
+	}
+	opf, err := book.ReadFile("EPUB/package.opf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(opf, []byte(`<meta property="dcterms:modified">2024-05-01T12:00:00Z</meta>`)) {
+		t.Logf("unexpected package document %s", opf)
+		t.Fail()
+	}
+}
+
+func Test_build_without_title(t *testing.T) {
+	builder := New(model.Metadata{Languages: &[]model.Language{model.ParseLanguage("en")}})
+	if _, err := builder.AddChapter("1.xhtml", "One", chapter("One")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := builder.Build(); err == nil {
+		t.Logf("expected error for missing title")
+		t.Fail()
+	}
+}
+
+func Test_build_spaced_file_name(t *testing.T) {
+	builder := New(model.Metadata{
+		Titles:    &[]model.Title{{Title: "Spaces"}},
+		Languages: &[]model.Language{model.ParseLanguage("en")},
+	})
+	if _, err := builder.AddChapter("text/chapter 1#.xhtml", "One", chapter("One")); err != nil {
+		t.Fatal(err)
+	}
+	built, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	book := epubtest.Reopen(t, built)
+	item := (*book.Manifest)[1]
+	if item.Href != "text/chapter%201%23.xhtml" {
+		t.Logf("unexpected href %s", item.Href)
+		t.Fail()
+	}
+	if _, err := book.ReadItemHTML(item); err != nil {
+		t.Logf("chapter cannot be read: %v", err)
+		t.Fail()
+	}
+	toc, err := book.ReadTOC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(toc) != 1 || toc[0].Href != "text/chapter 1#.xhtml" {
+		t.Logf("unexpected toc %v", toc)
+		t.Fail()
+	}
+}
+
+func Test_add_item_rejected(t *testing.T) {
+	builder := New(model.Metadata{})
+	for _, href := range []string{"/OEBPS/1.xhtml", "..", "../1.xhtml", "text/../../1.xhtml"} {
+		if _, err := builder.AddChapter(href, "One", chapter("One")); err == nil {
+			t.Logf("expected error for href %s", href)
+			t.Fail()
+		}
+	}
+	if _, err := builder.AddItem(model.ManifestItem{Id: "pub-id", Href: "1.xhtml", MediaType: "application/xhtml+xml"}, chapter("One")); err == nil {
+		t.Logf("expected error for the reserved id pub-id")
+		t.Fail()
+	}
+	item, err := builder.AddChapter("pub-id.xhtml", "One", chapter("One"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Id == "pub-id" {
+		t.Logf("generated the reserved id %s", item.Id)
+		t.Fail()
+	}
+}
@@ -0,0 +1,143 @@
+package builder
+
+import (
+	"encoding/xml"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mathieu-keller/epub-parser/model"
+)
+
+const containerDocument = `<?xml version="1.0" encoding="utf-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="` + packagePath + `" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+// NavDocument returns an EPUB 3 navigation document with the toc nav and, if
+// there are landmarks, a hidden landmarks nav. Hrefs are relative to the
+// navigation document.
+func NavDocument(title string, language string, heading string, toc []model.TOCEntry, landmarks []model.Landmark) []byte {
+	document := strings.Builder{}
+	document.WriteString(`<?xml version="1.0" encoding="utf-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="` + escape(language) + `" lang="` + escape(language) + `">
+<head>
+  <title>` + escape(title) + `</title>
+</head>
+<body>
+  <nav epub:type="toc" id="toc">
+    <h1>` + escape(heading) + `</h1>
+`)
+	writeNavList(&document, toc, "    ")
+	document.WriteString("  </nav>\n")
+	if len(landmarks) > 0 {
+		document.WriteString(`  <nav epub:type="landmarks" id="landmarks" hidden="hidden">
+    <h2>Landmarks</h2>
+    <ol>
+`)
+		for _, landmark := range landmarks {
+			document.WriteString(`      <li><a epub:type="` + escape(landmark.Type) + `" href="` + escape(href(landmark.Href, landmark.Fragment)) + `">` + escape(landmark.Title) + "</a></li>\n")
+		}
+		document.WriteString("    </ol>\n  </nav>\n")
+	}
+	document.WriteString("</body>\n</html>\n")
+	return []byte(document.String())
+}
+
+func writeNavList(document *strings.Builder, entries []model.TOCEntry, indent string) {
+	document.WriteString(indent + "<ol>\n")
+	for _, entry := range entries {
+		document.WriteString(indent + "  <li>")
+		if entry.Href != "" {
+			document.WriteString(`<a href="` + escape(entryHref(entry)) + `">` + escape(entry.Title) + "</a>")
+		} else {
+			document.WriteString("<span>" + escape(entry.Title) + "</span>")
+		}
+		if len(entry.Children) > 0 {
+			document.WriteString("\n")
+			writeNavList(document, entry.Children, indent+"    ")
+			document.WriteString(indent + "  ")
+		}
+		document.WriteString("</li>\n")
+	}
+	document.WriteString(indent + "</ol>\n")
+}
+
+// NCXDocument returns an EPUB 2 NCX with the same entries as the toc nav.
+// uid is the unique identifier of the book.
+func NCXDocument(uid string, title string, toc []model.TOCEntry) []byte {
+	document := strings.Builder{}
+	document.WriteString(`<?xml version="1.0" encoding="utf-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <head>
+    <meta name="dtb:uid" content="` + escape(uid) + `"/>
+    <meta name="dtb:depth" content="` + strconv.Itoa(max(depth(toc), 1)) + `"/>
+    <meta name="dtb:totalPageCount" content="0"/>
+    <meta name="dtb:maxPageNumber" content="0"/>
+  </head>
+  <docTitle>
+    <text>` + escape(title) + `</text>
+  </docTitle>
+  <navMap>
+`)
+	playOrder := 0
+	writeNavPoints(&document, toc, "    ", &playOrder)
+	document.WriteString("  </navMap>\n</ncx>\n")
+	return []byte(document.String())
+}
+
+func writeNavPoints(document *strings.Builder, entries []model.TOCEntry, indent string, playOrder *int) {
+	for _, entry := range entries {
+		src := firstHref(entry)
+		if src == "" {
+			continue
+		}
+		*playOrder++
+		order := strconv.Itoa(*playOrder)
+		document.WriteString(indent + `<navPoint id="navPoint` + order + `" playOrder="` + order + `">` + "\n")
+		document.WriteString(indent + "  <navLabel><text>" + escape(entry.Title) + "</text></navLabel>\n")
+		document.WriteString(indent + `  <content src="` + escape(src) + `"/>` + "\n")
+		writeNavPoints(document, entry.Children, indent+"  ", playOrder)
+		document.WriteString(indent + "</navPoint>\n")
+	}
+}
+
+// firstHref returns the href of an entry, or of its first child with one,
+// because NCX nav points must link somewhere.
+func firstHref(entry model.TOCEntry) string {
+	if entry.Href != "" {
+		return entryHref(entry)
+	}
+	for _, child := range entry.Children {
+		if href := firstHref(child); href != "" {
+			return href
+		}
+	}
+	return ""
+}
+
+func entryHref(entry model.TOCEntry) string {
+	return href(entry.Href, entry.Fragment)
+}
+
+func href(path string, fragment string) string {
+	return (&url.URL{Path: path, Fragment: fragment}).String()
+}
+
+func depth(entries []model.TOCEntry) int {
+	deepest := 0
+	for _, entry := range entries {
+		deepest = max(deepest, depth(entry.Children)+1)
+	}
+	return deepest
+}
+
+func escape(text string) string {
+	builder := strings.Builder{}
+	xml.EscapeText(&builder, []byte(text))
+	return builder.String()
+}
@@ -0,0 +1,361 @@
+package cfi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Side string
+
+const (
+	SideNone   Side = ""
+	SideBefore Side = "b"
+	SideAfter  Side = "a"
+)
+
+// CFI is an EPUB Canonical Fragment Identifier. For ranges Path is the
+// common parent and Start and End are relative to it.
+type CFI struct {
+	Path  Path
+	Range bool
+	Start Path
+	End   Path
+}
+
+type Path struct {
+	Steps  []Step
+	Offset Offset
+}
+
+type Step struct {
+	Index int
+	// Id is the id assertion of an element step.
+	Id string
+	// Indirection is true if the step follows a "!", i.e. it is the first
+	// step inside the document referenced by the previous step.
+	Indirection bool
+}
+
+type Offset struct {
+	Character    int
+	HasCharacter bool
+	Temporal     float64
+	HasTemporal  bool
+	X, Y         float64
+	HasSpatial   bool
+	// TextBefore and TextAfter are the text location assertion.
+	TextBefore string
+	TextAfter  string
+	Side       Side
+}
+
+// Parse parses a CFI like "epubcfi(/6/4[chap01ref]!/4[body01]/10[para05]/3:10)".
+// The "epubcfi(...)" wrapper and a leading "#" are optional.
+func Parse(value string) (CFI, error) {
+	raw := strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if inner, ok := strings.CutPrefix(raw, "epubcfi("); ok {
+		inner, ok = strings.CutSuffix(inner, ")")
+		if !ok {
+			return CFI{}, fmt.Errorf("invalid cfi %q: missing )", value)
+		}
+		raw = inner
+	}
+	parser := &parser{input: raw}
+	cfi := CFI{}
+	var err error
+	cfi.Path, err = parser.path()
+	if err != nil {
+		return CFI{}, fmt.Errorf("invalid cfi %q: %w", value, err)
+	}
+	if len(cfi.Path.Steps) == 0 {
+		return CFI{}, fmt.Errorf("invalid cfi %q: no steps", value)
+	}
+	if parser.peek() == ',' {
+		cfi.Range = true
+		parser.position++
+		cfi.Start, err = parser.path()
+		if err == nil {
+			err = parser.expect(',')
+		}
+		if err == nil {
+			cfi.End, err = parser.path()
+		}
+		if err != nil {
+			return CFI{}, fmt.Errorf("invalid cfi %q: %w", value, err)
+		}
+	}
+	if parser.position < len(parser.input) {
+		return CFI{}, fmt.Errorf("invalid cfi %q: unexpected %q at %d", value, parser.input[parser.position], parser.position)
+	}
+	return cfi, nil
+}
+
+// MustParse is like Parse but panics if the CFI is invalid.
+func MustParse(value string) CFI {
+	cfi, err := Parse(value)
+	if err != nil {
+		panic(err)
+	}
+	return cfi
+}
+
+type parser struct {
+	input    string
+	position int
+}
+
+func (parser *parser) peek() byte {
+	if parser.position >= len(parser.input) {
+		return 0
+	}
+	return parser.input[parser.position]
+}
+
+func (parser *parser) expect(char byte) error {
+	if parser.peek() != char {
+		return fmt.Errorf("expected %q at %d", char, parser.position)
+	}
+	parser.position++
+	return nil
+}
+
+func (parser *parser) path() (Path, error) {
+	path := Path{}
+	for {
+		indirection := false
+		if parser.peek() == '!' {
+			indirection = true
+			parser.position++
+		}
+		if parser.peek() != '/' {
+			if indirection {
+				return path, fmt.Errorf("expected step after ! at %d", parser.position)
+			}
+			break
+		}
+		parser.position++
+		start := parser.position
+		index, err := parser.integer()
+		if err != nil {
+			return path, err
+		}
+		if index == 0 {
+			return path, fmt.Errorf("step index 0 at %d, steps start at 1", start)
+		}
+		step := Step{Index: index, Indirection: indirection}
+		if parser.peek() == '[' {
+			values, _, err := parser.assertion()
+			if err != nil {
+				return path, err
+			}
+			step.Id = values[0]
+		}
+		path.Steps = append(path.Steps, step)
+	}
+	return path, parser.offset(&path.Offset)
+}
+
+func (parser *parser) offset(offset *Offset) error {
+	for {
+		var err error
+		switch parser.peek() {
+		case ':':
+			parser.position++
+			offset.Character, err = parser.integer()
+			offset.HasCharacter = true
+			if err == nil && parser.peek() == '[' {
+				err = parser.textAssertion(offset)
+			}
+		case '~':
+			parser.position++
+			offset.Temporal, err = parser.number()
+			offset.HasTemporal = true
+		case '@':
+			parser.position++
+			offset.X, err = parser.number()
+			if err == nil {
+				err = parser.expect(':')
+			}
+			if err == nil {
+				offset.Y, err = parser.number()
+			}
+			offset.HasSpatial = true
+		default:
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (parser *parser) textAssertion(offset *Offset) error {
+	values, parameters, err := parser.assertion()
+	if err != nil {
+		return err
+	}
+	offset.TextBefore = values[0]
+	if len(values) > 1 {
+		offset.TextAfter = values[1]
+	}
+	switch parameters["s"] {
+	case "b":
+		offset.Side = SideBefore
+	case "a":
+		offset.Side = SideAfter
+	}
+	return nil
+}
+
+// assertion reads "[value,value;param=value]" and returns the unescaped
+// values and parameters.
+func (parser *parser) assertion() ([]string, map[string]string, error) {
+	parser.position++
+	values := []string{""}
+	parameters := map[string]string{}
+	inParameters := false
+	current := &strings.Builder{}
+	for {
+		if parser.position >= len(parser.input) {
+			return nil, nil, fmt.Errorf("unterminated assertion")
+		}
+		char := parser.input[parser.position]
+		parser.position++
+		switch char {
+		case '^':
+			if parser.position >= len(parser.input) {
+				return nil, nil, fmt.Errorf("unterminated escape")
+			}
+			current.WriteByte(parser.input[parser.position])
+			parser.position++
+			continue
+		case ']', ';', ',':
+			if !inParameters {
+				values[len(values)-1] = current.String()
+			} else {
+				name, value, _ := strings.Cut(current.String(), "=")
+				parameters[strings.TrimSpace(name)] = value
+			}
+			current = &strings.Builder{}
+			switch {
+			case char == ']':
+				return values, parameters, nil
+			case char == ';':
+				inParameters = true
+			case !inParameters:
+				values = append(values, "")
+			}
+			continue
+		}
+		current.WriteByte(char)
+	}
+}
+
+func (parser *parser) integer() (int, error) {
+	start := parser.position
+	for parser.position < len(parser.input) && parser.input[parser.position] >= '0' && parser.input[parser.position] <= '9' {
+		parser.position++
+	}
+	if start == parser.position {
+		return 0, fmt.Errorf("expected integer at %d", start)
+	}
+	return strconv.Atoi(parser.input[start:parser.position])
+}
+
+func (parser *parser) number() (float64, error) {
+	start := parser.position
+	for parser.position < len(parser.input) && strings.IndexByte("0123456789.", parser.input[parser.position]) >= 0 {
+		parser.position++
+	}
+	if start == parser.position {
+		return 0, fmt.Errorf("expected number at %d", start)
+	}
+	return strconv.ParseFloat(parser.input[start:parser.position], 64)
+}
+
+// String serializes the CFI including the "epubcfi(...)" wrapper.
+func (cfi CFI) String() string {
+	builder := &strings.Builder{}
+	builder.WriteString("epubcfi(")
+	cfi.Path.write(builder)
+	if cfi.Range {
+		builder.WriteByte(',')
+		cfi.Start.write(builder)
+		builder.WriteByte(',')
+		cfi.End.write(builder)
+	}
+	builder.WriteByte(')')
+	return builder.String()
+}
+
+func (path Path) String() string {
+	builder := &strings.Builder{}
+	path.write(builder)
+	return builder.String()
+}
+
+func (path Path) write(builder *strings.Builder) {
+	for _, step := range path.Steps {
+		if step.Indirection {
+			builder.WriteByte('!')
+		}
+		builder.WriteByte('/')
+		builder.WriteString(strconv.Itoa(step.Index))
+		if step.Id != "" {
+			builder.WriteString("[" + escape(step.Id) + "]")
+		}
+	}
+	offset := path.Offset
+	if offset.HasCharacter {
+		builder.WriteString(":" + strconv.Itoa(offset.Character))
+		if offset.TextBefore != "" || offset.TextAfter != "" || offset.Side != SideNone {
+			builder.WriteString("[" + escape(offset.TextBefore))
+			if offset.TextAfter != "" {
+				builder.WriteString("," + escape(offset.TextAfter))
+			}
+			if offset.Side != SideNone {
+				builder.WriteString(";s=" + string(offset.Side))
+			}
+			builder.WriteByte(']')
+		}
+	}
+	if offset.HasTemporal {
+		builder.WriteString("~" + strconv.FormatFloat(offset.Temporal, 'f', -1, 64))
+	}
+	if offset.HasSpatial {
+		builder.WriteString("@" + strconv.FormatFloat(offset.X, 'f', -1, 64) + ":" + strconv.FormatFloat(offset.Y, 'f', -1, 64))
+	}
+}
+
+func escape(value string) string {
+	builder := strings.Builder{}
+	for _, r := range value {
+		if strings.ContainsRune("^[](),;=", r) {
+			builder.WriteByte('^')
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// StartPoint returns the start of a range as a point CFI, or the CFI itself.
+func (cfi CFI) StartPoint() CFI {
+	if !cfi.Range {
+		return cfi
+	}
+	return CFI{Path: cfi.Path.join(cfi.Start)}
+}
+
+// EndPoint returns the end of a range as a point CFI, or the CFI itself.
+func (cfi CFI) EndPoint() CFI {
+	if !cfi.Range {
+		return cfi
+	}
+	return CFI{Path: cfi.Path.join(cfi.End)}
+}
+
+func (path Path) join(local Path) Path {
+	steps := append(append([]Step{}, path.Steps...), local.Steps...)
+	return Path{Steps: steps, Offset: local.Offset}
+}
@@ -0,0 +1,143 @@
+package cfi
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func Test_parse_and_serialize(t *testing.T) {
+	tests := []string{
+		"epubcfi(/6/4[chap01ref]!/4[body01]/10[para05]/3:10)",
+		"epubcfi(/6/4!/4/10/2/1:3)",
+		"epubcfi(/6/4[chap01ref]!/4[body01]/16[svgimg])",
+		"epubcfi(/6/4!/4/2[id^[1^]],/1:1,/3:4)",
+		"epubcfi(/6/4[chap01ref]!/4[body01]/10[para05]/2/1:3[yyy])",
+		"epubcfi(/6/4!/4/2/1:3[xx,y])",
+		"epubcfi(/6/4!/4/2/1:3[,y])",
+		"epubcfi(/6/4!/4/2/1:3[Ph^,x;s=b])",
+		"epubcfi(/6/4!/4/2/1:0[;s=a])",
+		"epubcfi(/6/14[xchap_id]!/4/2[vid]~23.5@50:25.5)",
+	}
+	for _, test := range tests {
+		cfi, err := Parse(test)
+		if err != nil {
+			t.Logf("Parse(%q) failed: %v", test, err)
+			t.Fail()
+			continue
+		}
+		if actual := cfi.String(); actual != test {
+			t.Logf("Parse(%q).String() is %q", test, actual)
+			t.Fail()
+		}
+	}
+
+	cfi := MustParse("#epubcfi(/6/4[chap01ref]!/4[body01]/10[para^]05]/3:10[Ph^,x;s=b])")
+	steps := cfi.Path.Steps
+	if len(steps) != 5 || steps[1].Id != "chap01ref" || !steps[2].Indirection || steps[3].Id != "para]05" || steps[4].Index != 3 {
+		t.Logf("unexpected steps %+v", steps)
+		t.Fail()
+	}
+	offset := cfi.Path.Offset
+	if !offset.HasCharacter || offset.Character != 10 || offset.TextBefore != "Ph,x" || offset.Side != SideBefore {
+		t.Logf("unexpected offset %+v", offset)
+		t.Fail()
+	}
+
+	for _, invalid := range []string{"", "epubcfi()", "epubcfi(/6/4", "epubcfi(/6/a)", "epubcfi(/6/4!)", "epubcfi(/6/4[id)", "epubcfi(/6/4,/1:1)", "/6/4 x", "epubcfi(/6/0[x]!/4)"} {
+		if _, err := Parse(invalid); err == nil {
+			t.Logf("Parse(%q) expected an error", invalid)
+			t.Fail()
+		}
+	}
+}
+
+func Test_compare_and_sort(t *testing.T) {
+	cfis := []CFI{
+		MustParse("epubcfi(/6/4!/4/10/1:3)"),
+		MustParse("epubcfi(/6/6!/4/2/1:0)"),
+		MustParse("epubcfi(/6/4!/4/2,/1:5,/1:9)"),
+		MustParse("epubcfi(/6/4!/4/10)"),
+		MustParse("epubcfi(/6/4!/4/2/1:2)"),
+		MustParse("epubcfi(/6/4!/4/10/1:3[;s=b])"),
+	}
+	Sort(cfis)
+	expected := []string{
+		"epubcfi(/6/4!/4/2/1:2)",
+		"epubcfi(/6/4!/4/2,/1:5,/1:9)",
+		"epubcfi(/6/4!/4/10)",
+		"epubcfi(/6/4!/4/10/1:3[;s=b])",
+		"epubcfi(/6/4!/4/10/1:3)",
+		"epubcfi(/6/6!/4/2/1:0)",
+	}
+	for i := range expected {
+		if cfis[i].String() != expected[i] {
+			t.Logf("position %d expected %s but is %s", i, expected[i], cfis[i])
+			t.Fail()
+		}
+	}
+	if Compare(MustParse("epubcfi(/6/4!/4/2/1:2)"), MustParse("epubcfi(/6/4[x]!/4/2/1:2)")) != 0 {
+		t.Log("assertions must not change the order")
+		t.Fail()
+	}
+}
+
+const document = `<html xmlns="http://www.w3.org/1999/xhtml"><head><title>T</title></head><body id="body01">` +
+	`<p>First</p><p id="para02">Zwei <em>und</em> drei 😀 vier</p></body></html>`
+
+func Test_resolve(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path   string
+		node   string
+		offset int
+	}{
+		{"/4[body01]/4[para02]/1:2", "Zwei ", 2},
+		{"/4/4/3:1", " drei 😀 vier", 1},
+		{"/4/4/3:9", " drei 😀 vier", 11},
+		{"/4/4/3:100", " drei 😀 vier", 15},
+		{"/4/4/2/1:3", "und", 3},
+		{"/4/6[para02]/1:0", "Zwei ", 0},
+		{"/4/4", "p", 0},
+	}
+	for _, test := range tests {
+		cfi := MustParse(test.path)
+		location, err := Resolve(root, cfi.Path)
+		if err != nil {
+			t.Logf("Resolve(%s) failed: %v", test.path, err)
+			t.Fail()
+			continue
+		}
+		if location.Node.Data != test.node || location.Offset != test.offset {
+			t.Logf("Resolve(%s) expected %q at %d but is %q at %d", test.path, test.node, test.offset, location.Node.Data, location.Offset)
+			t.Fail()
+		}
+	}
+	for _, invalid := range []string{"/4/8/1:0", "/4/4/1/2", "/4/2/3:5"} {
+		if _, err := Resolve(root, MustParse(invalid).Path); err == nil {
+			t.Logf("Resolve(%s) expected an error", invalid)
+			t.Fail()
+		}
+	}
+}
+
+func Test_new_path(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+	paragraph := ElementById(root, "para02")
+	if path := NewPath(paragraph, 0).String(); path != "/4[body01]/4[para02]" {
+		t.Logf("expected /4[body01]/4[para02] but is %s", path)
+		t.Fail()
+	}
+	text := paragraph.LastChild
+	if path := NewPath(text, len(" drei 😀 ")).String(); path != "/4[body01]/4[para02]/3:9" {
+		t.Logf("expected /4[body01]/4[para02]/3:9 but is %s", path)
+		t.Fail()
+	}
+}
@@ -0,0 +1,62 @@
+package cfi
+
+import "sort"
+
+// Compare orders CFIs by their position in the book: it returns -1 if a is
+// before b, 1 if a is after b and 0 if both point to the same location.
+// Ranges are compared by their start.
+func Compare(a CFI, b CFI) int {
+	return comparePaths(a.StartPoint().Path, b.StartPoint().Path)
+}
+
+func comparePaths(a Path, b Path) int {
+	for i := 0; i < len(a.Steps) && i < len(b.Steps); i++ {
+		if result := compareNumbers(a.Steps[i].Index, b.Steps[i].Index); result != 0 {
+			return result
+		}
+	}
+	if result := compareNumbers(len(a.Steps), len(b.Steps)); result != 0 {
+		// A parent comes before its content.
+		return result
+	}
+	if result := compareNumbers(a.Offset.Character, b.Offset.Character); result != 0 {
+		return result
+	}
+	if result := compareNumbers(a.Offset.Temporal, b.Offset.Temporal); result != 0 {
+		return result
+	}
+	if result := compareNumbers(a.Offset.Y, b.Offset.Y); result != 0 {
+		return result
+	}
+	if result := compareNumbers(a.Offset.X, b.Offset.X); result != 0 {
+		return result
+	}
+	return compareNumbers(sideOrder(a.Offset.Side), sideOrder(b.Offset.Side))
+}
+
+func sideOrder(side Side) int {
+	switch side {
+	case SideBefore:
+		return -1
+	case SideAfter:
+		return 1
+	}
+	return 0
+}
+
+func compareNumbers[T int | float64](a T, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Sort sorts CFIs in reading order.
+func Sort(cfis []CFI) {
+	sort.SliceStable(cfis, func(i, j int) bool {
+		return Compare(cfis[i], cfis[j]) < 0
+	})
+}
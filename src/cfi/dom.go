@@ -0,0 +1,191 @@
+package cfi
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf16"
+
+	"golang.org/x/net/html"
+)
+
+// Location is a node of a content document. For text nodes Offset is the
+// byte offset in Node.Data.
+type Location struct {
+	Node   *html.Node
+	Offset int
+}
+
+// SplitIndirection splits the steps of a path at the first indirection into
+// the steps in the package document and the path inside the content document.
+func SplitIndirection(path Path) ([]Step, Path, error) {
+	for i, step := range path.Steps {
+		if step.Indirection {
+			local := Path{Steps: append([]Step{}, path.Steps[i:]...), Offset: path.Offset}
+			local.Steps[0].Indirection = false
+			return path.Steps[:i], local, nil
+		}
+	}
+	return nil, Path{}, errors.New("cfi does not reference a content document")
+}
+
+// Resolve resolves a path relative to the root element of a parsed content
+// document. Ids of element steps are used to recover if the document changed
+// and the step index no longer points to the element with that id.
+func Resolve(document *html.Node, path Path) (Location, error) {
+	node := rootElement(document)
+	if node == nil {
+		return Location{}, errors.New("document has no root element")
+	}
+	for i, step := range path.Steps {
+		if step.Indirection {
+			return Location{}, errors.New("indirection inside content documents is not supported")
+		}
+		if step.Index%2 == 1 {
+			if i != len(path.Steps)-1 {
+				return Location{}, fmt.Errorf("text step /%d must be the last step", step.Index)
+			}
+			return resolveText(node, step.Index, path.Offset)
+		}
+		child := childElement(node, step.Index/2-1)
+		if step.Id != "" && (child == nil || attribute(child, "id") != step.Id) {
+			if byId := ElementById(document, step.Id); byId != nil {
+				child = byId
+			}
+		}
+		if child == nil {
+			return Location{}, fmt.Errorf("step /%d does not exist", step.Index)
+		}
+		node = child
+	}
+	return Location{Node: node}, nil
+}
+
+// resolveText finds the text node containing the character offset in the
+// text between the element children (index-1)/2 and (index+1)/2 of parent.
+func resolveText(parent *html.Node, index int, offset Offset) (Location, error) {
+	elements := 0
+	remaining := offset.Character
+	var last *html.Node
+	for child := parent.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode {
+			elements++
+			continue
+		}
+		if child.Type != html.TextNode || elements != (index-1)/2 {
+			continue
+		}
+		last = child
+		length := utf16Length(child.Data)
+		if remaining <= length {
+			return Location{Node: child, Offset: byteOffset(child.Data, remaining)}, nil
+		}
+		remaining -= length
+	}
+	if last != nil {
+		// The offset is past the end of the text, e.g. because the document
+		// changed. Clamp it to the end.
+		return Location{Node: last, Offset: len(last.Data)}, nil
+	}
+	if offset.Character > 0 {
+		return Location{}, fmt.Errorf("step /%d has no text", index)
+	}
+	return Location{Node: parent}, nil
+}
+
+// NewPath returns the path from the root element of a content document to a
+// node. For text nodes byteOffset is the byte offset in Node.Data.
+func NewPath(node *html.Node, byteOffset int) Path {
+	if node.Type != html.TextNode {
+		return Path{Steps: elementSteps(node)}
+	}
+	index := 0
+	character := 0
+	for sibling := node.Parent.FirstChild; sibling != node; sibling = sibling.NextSibling {
+		if sibling.Type == html.ElementNode {
+			index++
+			character = 0
+		} else if sibling.Type == html.TextNode {
+			character += utf16Length(sibling.Data)
+		}
+	}
+	character += utf16Length(node.Data[:byteOffset])
+	steps := append(elementSteps(node.Parent), Step{Index: index*2 + 1})
+	return Path{Steps: steps, Offset: Offset{Character: character, HasCharacter: true}}
+}
+
+func elementSteps(element *html.Node) []Step {
+	var steps []Step
+	for node := element; node != nil && node.Parent != nil && node.Parent.Type != html.DocumentNode; node = node.Parent {
+		index := 0
+		for sibling := node.Parent.FirstChild; sibling != node; sibling = sibling.NextSibling {
+			if sibling.Type == html.ElementNode {
+				index++
+			}
+		}
+		steps = append(steps, Step{Index: (index + 1) * 2, Id: attribute(node, "id")})
+	}
+	for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+	return steps
+}
+
+// ElementById returns the element with the given id attribute.
+func ElementById(node *html.Node, id string) *html.Node {
+	if node.Type == html.ElementNode && attribute(node, "id") == id {
+		return node
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if found := ElementById(child, id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func rootElement(document *html.Node) *html.Node {
+	if document.Type == html.ElementNode {
+		return document
+	}
+	return childElement(document, 0)
+}
+
+func childElement(node *html.Node, index int) *html.Node {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode {
+			if index == 0 {
+				return child
+			}
+			index--
+		}
+	}
+	return nil
+}
+
+func attribute(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key && attr.Namespace == "" {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func utf16Length(text string) int {
+	length := 0
+	for _, r := range text {
+		length += utf16.RuneLen(r)
+	}
+	return length
+}
+
+// byteOffset converts an offset in UTF-16 code units to a byte offset.
+func byteOffset(text string, units int) int {
+	for i, r := range text {
+		if units <= 0 {
+			return i
+		}
+		units -= utf16.RuneLen(r)
+	}
+	return len(text)
+}
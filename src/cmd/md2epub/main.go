@@ -0,0 +1,49 @@
+// Command md2epub converts a directory of Markdown chapters to an EPUB 3 file.
+//
+//	md2epub [-ncx] [-toc-depth 2] -o book.epub chapters/
+//
+// The chapters are read in the order of their file names; the YAML front
+// matter of the first one sets title, author, language, identifier and cover.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mathieu-keller/epub-parser/markdown"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stderr))
+}
+
+// run converts the directory named in args and returns the exit code.
+func run(args []string, stderr io.Writer) int {
+	flags := flag.NewFlagSet("md2epub", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("o", "book.epub", "output file")
+	ncx := flags.Bool("ncx", false, "add an EPUB 2 NCX table of contents")
+	tocDepth := flags.Int("toc-depth", 2, "deepest heading level in the table of contents")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: md2epub [flags] directory\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	book, err := markdown.Convert(os.DirFS(flags.Arg(0)), markdown.Options{TOCDepth: *tocDepth, NCX: *ncx})
+	if err == nil {
+		err = book.SaveAs(*output)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "md2epub:", err)
+		return 1
+	}
+	return 0
+}
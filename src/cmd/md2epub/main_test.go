@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mathieu-keller/epub-parser/internal/epubtest"
+)
+
+func Test_run(t *testing.T) {
+	input := t.TempDir()
+	files := map[string]string{
+		"chapter 1.md": "---\ntitle: Spaces\n---\n# One\n\nOn to [two](<chapter 2.md#end>).\n",
+		"chapter 2.md": "# Two\n\n## End\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(input, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	output := filepath.Join(t.TempDir(), "book.epub")
+	stderr := new(bytes.Buffer)
+	if code := run([]string{"-ncx", "-o", output, input}, stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	book := epubtest.OpenFile(t, output)
+	if len(*book.Spine) != 2 {
+		t.Fatalf("unexpected spine %v", *book.Spine)
+	}
+	item, ok := book.ManifestItem((*book.Spine)[0].IdRef)
+	if !ok || item.Href != "chapter%201.xhtml" {
+		t.Logf("unexpected chapter %v", item)
+		t.Fail()
+	}
+	if _, err := book.ReadItemHTML(item); err != nil {
+		t.Logf("chapter cannot be read: %v", err)
+		t.Fail()
+	}
+	chapter, err := book.ReadFile("EPUB/chapter 1.xhtml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(chapter), `<a href="chapter%202.xhtml#end">two</a>`) {
+		t.Logf("unexpected chapter %s", chapter)
+		t.Fail()
+	}
+	toc, err := book.ReadTOC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(toc) != 2 || toc[1].Href != "chapter 2.xhtml" {
+		t.Logf("unexpected toc %v", toc)
+		t.Fail()
+	}
+}
+
+func Test_run_usage(t *testing.T) {
+	stderr := new(bytes.Buffer)
+	if code := run(nil, stderr); code != 2 || !strings.Contains(stderr.String(), "usage: md2epub") {
+		t.Logf("unexpected exit code %d: %s", code, stderr)
+		t.Fail()
+	}
+}
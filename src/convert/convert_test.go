@@ -0,0 +1,298 @@
+package convert
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mathieu-keller/epub-parser/internal/epubtest"
+	"github.com/mathieu-keller/epub-parser/model"
+)
+
+const containerXML = `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>`
+
+const epub2Package = `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">
+    <dc:title>Old Book</dc:title>
+    <dc:creator opf:role="aut" opf:file-as="Doe, Jane">Jane Doe</dc:creator>
+    <dc:identifier id="id" opf:scheme="ISBN">9780000000000</dc:identifier>
+    <dc:identifier opf:scheme="calibre">1234</dc:identifier>
+    <dc:language>en</dc:language>
+    <dc:date opf:event="publication">2001-02-03</dc:date>
+    <dc:date opf:event="creation">2000</dc:date>
+    <dc:date opf:event="modification">2005</dc:date>
+    <meta name="cover" content="cover"/>
+  </metadata>
+  <manifest>
+    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+    <item id="cover" href="cover.jpg" media-type="image/jpeg"/>
+    <item id="c1" href="c1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine toc="ncx">
+    <itemref idref="c1"/>
+  </spine>
+  <guide>
+    <reference type="text" title="Start" href="c1.xhtml"/>
+  </guide>
+</package>`
+
+const epub2NCX = `<?xml version="1.0" encoding="utf-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <navMap>
+    <navPoint id="n1" playOrder="1">
+      <navLabel><text>Chapter 1</text></navLabel>
+      <content src="c1.xhtml"/>
+    </navPoint>
+  </navMap>
+</ncx>`
+
+const chapterXHTML = `<?xml version="1.0" encoding="utf-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title>1</title></head>
+<body><p>Text</p><svg xmlns="http://www.w3.org/2000/svg"/></body></html>`
+
+func Test_upgrade(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"META-INF/container.xml": containerXML,
+		"OEBPS/content.opf":      epub2Package,
+		"OEBPS/toc.ncx":          epub2NCX,
+		"OEBPS/cover.jpg":        "jpeg",
+		"OEBPS/c1.xhtml":         chapterXHTML,
+	})
+	err := Upgrade(book)
+	if err != nil {
+		t.Fatal(err)
+	}
+	book = epubtest.Reopen(t, book)
+	if book.Version != "3.0" {
+		t.Logf("expected version 3.0 but got %s", book.Version)
+		t.Fail()
+	}
+	creator := (*book.Metadata.Creators)[0]
+	if creator.RawRole != "aut" || creator.FileAs != "Doe, Jane" || creator.FileAsOrigin != model.OriginBook {
+		t.Logf("unexpected creator %v", creator)
+		t.Fail()
+	}
+	if book.Metadata.MainId != (model.Identifier{Scheme: "urn", Id: "isbn:9780000000000"}) {
+		t.Logf("unexpected identifier %v", book.Metadata.MainId)
+		t.Fail()
+	}
+	if len(*book.Metadata.Dates) != 1 || (*book.Metadata.Dates)[0] != "2001-02-03" {
+		t.Logf("unexpected dates %v", *book.Metadata.Dates)
+		t.Fail()
+	}
+	if cover, ok := book.ManifestItemByProperty("cover-image"); !ok || cover.Id != "cover" {
+		t.Logf("cover-image property missing")
+		t.Fail()
+	}
+	if chapter, _ := book.ManifestItem("c1"); !chapter.HasProperty("svg") {
+		t.Logf("svg property missing")
+		t.Fail()
+	}
+	toc, err := book.ReadTOC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nav, ok := book.ManifestItemByProperty("nav"); !ok || nav.Href != "nav.xhtml" || len(toc) != 1 || toc[0].Title != "Chapter 1" {
+		t.Logf("unexpected nav %v %v", nav, toc)
+		t.Fail()
+	}
+	if landmark, ok := book.Landmark("bodymatter"); !ok || landmark.Source != model.LandmarkSourceNav {
+		t.Logf("unexpected landmark %v", landmark)
+		t.Fail()
+	}
+	data, err := book.ReadFile("OEBPS/content.opf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opf := string(data)
+	for _, expected := range []string{
+		`version="3.0"`,
+		`<meta property="dcterms:created">2000</meta>`,
+		`<meta property="dcterms:modified">`,
+		`<dc:date>2001-02-03</dc:date>`,
+		`<meta refines="#creator1" property="role" scheme="marc:relators">aut</meta>`,
+		`<dc:identifier id="id">urn:isbn:9780000000000</dc:identifier>`,
+		`<dc:identifier id="identifier1">1234</dc:identifier>`,
+		`<meta refines="#identifier1" property="identifier-type">calibre</meta>`,
+	} {
+		if !strings.Contains(opf, expected) {
+			t.Logf("expected %s in\n%s", expected, opf)
+			t.Fail()
+		}
+	}
+	if strings.Contains(opf, "opf:") && !strings.Contains(opf, `xmlns:opf=`) || strings.Contains(opf, "2005") {
+		t.Logf("unexpected package document\n%s", opf)
+		t.Fail()
+	}
+}
+
+func Test_upgrade_file(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"META-INF/container.xml": containerXML,
+		"OEBPS/content.opf":      epub2Package,
+		"OEBPS/toc.ncx":          epub2NCX,
+		"OEBPS/cover.jpg":        "jpeg",
+		"OEBPS/c1.xhtml":         chapterXHTML,
+	})
+	input := filepath.Join(t.TempDir(), "old.epub")
+	if err := book.SaveAs(input); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "new.epub")
+	if err := UpgradeFile(input, output); err != nil {
+		t.Fatal(err)
+	}
+	if upgraded := epubtest.OpenFile(t, output); upgraded.Version != "3.0" {
+		t.Logf("expected version 3.0 but got %s", upgraded.Version)
+		t.Fail()
+	}
+	if original := epubtest.OpenFile(t, input); original.Version != "2.0" {
+		t.Logf("expected the input to stay EPUB 2 but got %s", original.Version)
+		t.Fail()
+	}
+	if err := UpgradeFile(input, input); err == nil {
+		t.Logf("expected error when writing to the input")
+		t.Fail()
+	}
+}
+
+func Test_upgrade_rejects_epub_3(t *testing.T) {
+	book := &model.Book{Version: "3.0"}
+	if err := Upgrade(book); err == nil {
+		t.Logf("expected error")
+		t.Fail()
+	}
+}
+
+const epub3Package = `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id" prefix="rendition: http://www.idpf.org/vocab/rendition/#" dir="ltr">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:isbn:9780000000000</dc:identifier>
+    <dc:title id="t1">New Book</dc:title>
+    <meta refines="#t1" property="file-as">Book, New</meta>
+    <dc:creator id="c1">Jane Doe</dc:creator>
+    <meta refines="#c1" property="role" scheme="marc:relators">ill</meta>
+    <meta refines="#c1" property="file-as">Doe, Jane</meta>
+    <dc:language>en</dc:language>
+    <dc:publisher dir="ltr">Publisher</dc:publisher>
+    <meta property="dcterms:modified">2024-01-02T03:04:05Z</meta>
+    <meta property="rendition:layout">pre-paginated</meta>
+    <meta property="media:duration">0:01:00</meta>
+    <link rel="record" href="record.xml" media-type="application/marc"/>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="cover" href="cover.jpg" media-type="image/jpeg" properties="cover-image"/>
+    <item id="c1" href="c1.xhtml" media-type="application/xhtml+xml" media-overlay="s1"/>
+    <item id="quiz" href="quiz.xhtml" media-type="application/xhtml+xml" properties="scripted" fallback="c1"/>
+    <item id="s1" href="c1.smil" media-type="application/smil+xml"/>
+    <item id="audio" href="c1.mp3" media-type="audio/mpeg"/>
+  </manifest>
+  <spine page-progression-direction="rtl">
+    <itemref idref="c1" properties="page-spread-right"/>
+    <itemref idref="quiz"/>
+  </spine>
+</package>`
+
+const epub3Nav = `<?xml version="1.0" encoding="utf-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops"><head><title>Nav</title></head>
+<body>
+<nav epub:type="toc"><ol><li><a href="c1.xhtml">Chapter 1</a></li></ol></nav>
+<nav epub:type="landmarks"><ol><li><a epub:type="bodymatter" href="c1.xhtml">Start</a></li></ol></nav>
+</body></html>`
+
+func Test_downgrade(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"META-INF/container.xml": containerXML,
+		"OEBPS/content.opf":      epub3Package,
+		"OEBPS/nav.xhtml":        epub3Nav,
+		"OEBPS/cover.jpg":        "jpeg",
+		"OEBPS/c1.xhtml":         chapterXHTML,
+		"OEBPS/quiz.xhtml":       chapterXHTML,
+		"OEBPS/c1.smil":          "<smil/>",
+		"OEBPS/c1.mp3":           "mp3",
+	})
+	losses, err := Downgrade(book)
+	if err != nil {
+		t.Fatal(err)
+	}
+	book = epubtest.Reopen(t, book)
+	if book.Version != "2.0" {
+		t.Logf("expected version 2.0 but got %s", book.Version)
+		t.Fail()
+	}
+	creator := (*book.Metadata.Creators)[0]
+	if creator.RawRole != "ill" || creator.FileAs != "Doe, Jane" {
+		t.Logf("unexpected creator %v", creator)
+		t.Fail()
+	}
+	if title := (*book.Metadata.Titles)[0]; title.FileAs != "Book, New" || title.FileAsOrigin != model.OriginBook {
+		t.Logf("unexpected title %v", title)
+		t.Fail()
+	}
+	if book.Metadata.MainId != (model.Identifier{Scheme: "ISBN", Id: "9780000000000"}) {
+		t.Logf("unexpected identifier %v", book.Metadata.MainId)
+		t.Fail()
+	}
+	if len(*book.Spine) != 1 || (*book.Spine)[0].IdRef != "c1" {
+		t.Logf("expected scripted item to be replaced by its fallback but got %v", *book.Spine)
+		t.Fail()
+	}
+	toc, err := book.ReadTOC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(toc) != 1 || toc[0].Title != "Chapter 1" {
+		t.Logf("unexpected toc %v", toc)
+		t.Fail()
+	}
+	if landmark, ok := book.Landmark("bodymatter"); !ok || landmark.RawType != "text" {
+		t.Logf("unexpected landmark %v", landmark)
+		t.Fail()
+	}
+	data, err := book.ReadFile("OEBPS/content.opf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opf := string(data)
+	for _, expected := range []string{
+		`<dc:date opf:event="modification">2024-01-02T03:04:05Z</dc:date>`,
+		`<dc:creator opf:role="ill" opf:file-as="Doe, Jane">Jane Doe</dc:creator>`,
+		`<meta name="rendition:layout" content="pre-paginated"/>`,
+		`<meta name="cover" content="cover"/>`,
+		`<dc:publisher>Publisher</dc:publisher>`,
+		`<spine toc="ncx">`,
+		`<reference type="text" title="Start" href="c1.xhtml"/>`,
+	} {
+		if !strings.Contains(opf, expected) {
+			t.Logf("expected %s in\n%s", expected, opf)
+			t.Fail()
+		}
+	}
+	for _, unexpected := range []string{"refines", "properties", "media-overlay", "smil", "<link", "prefix=", "dir="} {
+		if strings.Contains(opf, unexpected) {
+			t.Logf("unexpected %s in\n%s", unexpected, opf)
+			t.Fail()
+		}
+	}
+	features := map[string]bool{}
+	for _, loss := range losses {
+		features[loss.Feature] = true
+		if loss.Feature == "manifest-property" && loss.Location != "nav" {
+			t.Logf("unexpected loss %s", loss)
+			t.Fail()
+		}
+	}
+	for _, feature := range []string{"media-overlay", "scripted", "link", "rendition", "manifest-property", "media-type", "page-progression-direction"} {
+		if !features[feature] {
+			t.Logf("expected loss of %s in %v", feature, losses)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package convert
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/mathieu-keller/epub-parser/builder"
+	"github.com/mathieu-keller/epub-parser/epub_v2"
+	"github.com/mathieu-keller/epub-parser/model"
+	"github.com/mathieu-keller/epub-parser/opf"
+)
+
+// Loss is an EPUB 3 feature that was dropped or replaced by Downgrade.
+type Loss struct {
+	// Feature is e.g. "media-overlay", "scripted", "refines", "link",
+	// "rendition", "manifest-property", "media-type" or
+	// "page-progression-direction".
+	Feature string
+	// Location is the manifest item id, meta property or element concerned.
+	Location string
+	Message  string
+}
+
+func (loss Loss) String() string {
+	return loss.Feature + " " + loss.Location + ": " + loss.Message
+}
+
+// landmarkGuideTypes maps EPUB 3 landmark types to guide reference types
+// where they differ.
+var landmarkGuideTypes = map[string]string{
+	"acknowledgments": "acknowledgements",
+	"bodymatter":      "text",
+	"endnotes":        "notes",
+	"titlepage":       "title-page",
+}
+
+var guideReferenceTypes = []string{
+	"acknowledgements", "bibliography", "colophon", "copyright-page", "cover", "dedication", "epigraph",
+	"foreword", "glossary", "index", "loi", "lot", "notes", "preface", "text", "title-page", "toc",
+}
+
+// Downgrade converts an EPUB 3 book to EPUB 2 in memory. Refining metas become
+// opf:role, opf:file-as and opf:scheme attributes, an NCX is generated from
+// the navigation document if the book has none and landmarks become the
+// guide. Features EPUB 2 has no equivalent for are dropped, or replaced by
+// their fallback, and returned as losses. Scripted documents without a
+// fallback stay in the spine with their scripts and are only reported. The
+// book is unchanged if an error is returned. Write the result with
+// model.Book.WriteTo or SaveAs.
+func Downgrade(book *model.Book) ([]Loss, error) {
+	if !strings.HasPrefix(book.Version, "3") {
+		return nil, fmt.Errorf("book is EPUB %s, not EPUB 3", book.Version)
+	}
+	converted := book.Copy()
+	losses, err := downgrade(converted)
+	if err != nil {
+		return nil, err
+	}
+	*book = *converted
+	return losses, nil
+}
+
+func downgrade(book *model.Book) ([]Loss, error) {
+	packagePath := book.Container.Rootfile.Path
+	toc, err := book.ReadTOC()
+	if err != nil {
+		return nil, err
+	}
+	data, err := book.ReadFile(packagePath)
+	if err != nil {
+		return nil, err
+	}
+	document, err := opf.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := document.Prefix(opf.NamespaceOpf); !ok {
+		// Declared once for the opf: attributes written below.
+		document.SetAttribute(document.Package, "xmlns:opf", opf.NamespaceOpf)
+		book.WriteFile(packagePath, document.Bytes())
+	}
+	book.Metadata.MainId = downgradeIdentifier(book.Metadata.MainId)
+	identifiers := append([]model.Identifier{}, model.Values(book.Metadata.Identifiers)...)
+	for i := range identifiers {
+		identifiers[i] = downgradeIdentifier(identifiers[i])
+	}
+	book.Metadata.Identifiers = &identifiers
+	// The EPUB 2 writer rewrites creators, titles and identifiers whose
+	// refining metas it does not see, removing those metas.
+	data, err = epub_v2.WriteOpf(book)
+	if err != nil {
+		return nil, err
+	}
+	document, err = opf.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	downgrader := &downgrader{book: book, document: document}
+	downgrader.metadata()
+	downgrader.manifest()
+	downgrader.spine()
+	downgrader.ncx(toc)
+	downgrader.guide()
+	book.WriteFile(packagePath, document.Bytes())
+	book.Version = "2.0"
+	book.Metadata = model.Metadata{}
+	return downgrader.losses, epub_v2.ParseOpf(book)
+}
+
+type downgrader struct {
+	book     *model.Book
+	document *opf.Document
+	losses   []Loss
+}
+
+func (downgrader *downgrader) lose(feature string, location string, message string) {
+	downgrader.losses = append(downgrader.losses, Loss{Feature: feature, Location: location, Message: message})
+}
+
+// metadata removes what EPUB 2 does not know: the version 3 package
+// attributes, dir attributes, links, refining metas and property metas.
+// dcterms:modified becomes a modification date, other properties name/content
+// metas.
+func (downgrader *downgrader) metadata() {
+	document := downgrader.document
+	document.SetAttribute(document.Package, "version", "2.0")
+	for _, attr := range []string{"prefix", "dir"} {
+		if document.Package.Attribute("", attr) != "" {
+			document.RemoveAttribute(document.Package, attr)
+		}
+	}
+	for _, element := range document.Metadata {
+		if element.Attribute("", "dir") != "" {
+			document.RemoveAttribute(element, "dir")
+		}
+		property := element.Attribute("", "property")
+		switch {
+		case element.Name.Local == "link":
+			document.Remove(element)
+			downgrader.lose("link", element.Attribute("", "href"), "metadata links are not supported")
+		case element.Name.Local != "meta" || property == "":
+		case element.Refines() != "":
+			document.Remove(element)
+			downgrader.lose("refines", property, fmt.Sprintf("%q refining #%s dropped", element.Text, element.Refines()))
+		case property == "dcterms:modified":
+			name, declarations := document.QualifiedName(opf.NamespaceDC, "date", "dc")
+			event, declaration := document.QualifiedName(opf.NamespaceOpf, "event", "opf")
+			attrs := append(append(declarations, declaration...), event, "modification")
+			document.InsertBefore(element, []string{opf.Markup(name, element.Text, attrs...)})
+			document.Remove(element)
+		case strings.HasPrefix(property, "media:"):
+			document.Remove(element)
+			downgrader.lose("media-overlay", property, "media overlays are not supported")
+		default:
+			if strings.HasPrefix(property, "rendition:") {
+				downgrader.lose("rendition", property, "kept as meta, EPUB 2 reading systems ignore it")
+			}
+			document.InsertBefore(element, []string{opf.Markup("meta", "", "name", property, "content", element.Text)})
+			document.Remove(element)
+		}
+	}
+}
+
+// manifest removes properties and media overlays, and marks the cover image
+// with a cover meta.
+func (downgrader *downgrader) manifest() {
+	document := downgrader.document
+	core := model.CoreMediaTypes("2.0")
+	hasCoverMeta := false
+	for _, element := range document.Metadata {
+		hasCoverMeta = hasCoverMeta || element.Name.Local == "meta" && element.Attribute("", "name") == "cover"
+	}
+	for _, element := range document.Manifest {
+		id := element.Attribute("", "id")
+		item, _ := downgrader.book.ManifestItem(id)
+		if item.MediaType == "application/smil+xml" {
+			document.Remove(element)
+			downgrader.lose("media-overlay", id, "media overlay document removed")
+			continue
+		}
+		if element.Attribute("", "media-overlay") != "" {
+			document.RemoveAttribute(element, "media-overlay")
+		}
+		if properties := element.Attribute("", "properties"); properties != "" {
+			document.RemoveAttribute(element, "properties")
+			for _, property := range strings.Fields(properties) {
+				// The cover image gets a cover meta, scripts are reported below.
+				if property != "cover-image" && property != "scripted" {
+					downgrader.lose("manifest-property", id, "property "+property+" dropped")
+				}
+			}
+		}
+		if item.HasProperty("cover-image") && !hasCoverMeta {
+			document.Append([]string{opf.Markup("meta", "", "name", "cover", "content", id)})
+			hasCoverMeta = true
+		}
+		if item.HasProperty("scripted") {
+			if item.Fallback != "" {
+				downgrader.lose("scripted", id, "replaced by fallback "+item.Fallback+" in the spine")
+			} else {
+				downgrader.lose("scripted", id, "scripts are not supported")
+			}
+		}
+		if !slices.Contains(core, item.MediaType) && item.Fallback == "" && item.MediaType != "application/x-dtbncx+xml" {
+			downgrader.lose("media-type", id, item.MediaType+" is not an EPUB 2 core media type")
+		}
+	}
+}
+
+// spine removes itemref properties and the page progression direction and
+// replaces scripted documents by their fallback.
+func (downgrader *downgrader) spine() {
+	document := downgrader.document
+	if direction := document.Spine.Attribute("", "page-progression-direction"); direction != "" {
+		document.RemoveAttribute(document.Spine, "page-progression-direction")
+		if direction == string(model.DirectionRTL) {
+			downgrader.lose("page-progression-direction", "spine", "right-to-left page progression is not supported")
+		}
+	}
+	referenced := map[string]bool{}
+	for _, element := range document.Itemrefs {
+		referenced[element.Attribute("", "idref")] = true
+	}
+	for _, element := range document.Itemrefs {
+		if properties := element.Attribute("", "properties"); properties != "" {
+			document.RemoveAttribute(element, "properties")
+			downgrader.lose("rendition", element.Attribute("", "idref"), "itemref properties "+properties+" dropped")
+		}
+		item, ok := downgrader.book.ManifestItem(element.Attribute("", "idref"))
+		if ok && item.HasProperty("scripted") && item.Fallback != "" {
+			if referenced[item.Fallback] {
+				document.Remove(element)
+			} else {
+				document.SetAttribute(element, "idref", item.Fallback)
+				referenced[item.Fallback] = true
+			}
+		}
+	}
+}
+
+// ncx generates an NCX from the table of contents if the book has none.
+func (downgrader *downgrader) ncx(toc []model.TOCEntry) {
+	document := downgrader.document
+	ncxId := ""
+	for _, item := range model.Values(downgrader.book.Manifest) {
+		if item.MediaType == "application/x-dtbncx+xml" {
+			ncxId = item.Id
+		}
+	}
+	if ncxId == "" {
+		href := freeHref(downgrader.book, "toc", ".ncx")
+		uid := downgrader.book.Metadata.MainId.Id
+		if scheme := downgrader.book.Metadata.MainId.Scheme; scheme != "" && !strings.Contains(uid, ":") {
+			uid = scheme + ":" + uid
+		}
+		downgrader.book.WriteFile(downgrader.book.ItemPath(model.ManifestItem{Href: href}), builder.NCXDocument(uid, firstTitle(downgrader.book), toc))
+		ncxId = "ncx"
+		if document.HasId(ncxId) {
+			ncxId = document.NewId(ncxId)
+		}
+		document.AppendManifest([]string{opf.Markup("item", "", "id", ncxId, "href", href, "media-type", "application/x-dtbncx+xml")})
+	}
+	if document.Spine.Attribute("", "toc") == "" {
+		document.SetAttribute(document.Spine, "toc", ncxId)
+	}
+}
+
+// guide adds a guide made of the landmarks if the book has none.
+func (downgrader *downgrader) guide() {
+	if downgrader.document.HasSection("guide") || len(downgrader.book.Landmarks) == 0 {
+		return
+	}
+	markup := []string{"<guide>"}
+	for _, landmark := range downgrader.book.Landmarks {
+		referenceType, ok := landmarkGuideTypes[landmark.Type]
+		if !ok {
+			referenceType = landmark.Type
+			if !slices.Contains(guideReferenceTypes, referenceType) {
+				referenceType = "other." + referenceType
+			}
+		}
+		title := landmark.Title
+		if title == "" {
+			title = landmark.Type
+		}
+		href := (&url.URL{Path: landmark.Href, Fragment: landmark.Fragment}).String()
+		markup = append(markup, "  "+opf.Markup("reference", "", "type", referenceType, "title", title, "href", href))
+	}
+	downgrader.document.AppendPackage(append(markup, "</guide>"))
+}
+
+// downgradeIdentifier turns URNs into identifiers with an opf:scheme, e.g.
+// urn:isbn:9780000000000 into ISBN 9780000000000. UUIDs keep their URN.
+func downgradeIdentifier(identifier model.Identifier) model.Identifier {
+	value := identifier.Id
+	if identifier.Scheme != "" {
+		value = identifier.Scheme + ":" + identifier.Id
+	}
+	lower := strings.ToLower(value)
+	for scheme, namespace := range identifierSchemes {
+		if strings.HasPrefix(lower, "urn:"+namespace+":") {
+			if scheme == "uuid" {
+				return model.Identifier{Id: value, Scheme: "UUID"}
+			}
+			return model.Identifier{Id: value[len("urn:"+namespace+":"):], Scheme: strings.ToUpper(scheme)}
+		}
+	}
+	return model.Identifier{Id: value}
+}
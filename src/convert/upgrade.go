@@ -0,0 +1,300 @@
+package convert
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	epub "github.com/mathieu-keller/epub-parser"
+	"github.com/mathieu-keller/epub-parser/builder"
+	"github.com/mathieu-keller/epub-parser/epub_v3"
+	"github.com/mathieu-keller/epub-parser/model"
+	"github.com/mathieu-keller/epub-parser/opf"
+	"golang.org/x/net/html"
+)
+
+// dateProperties maps opf:event values to the EPUB 3 property of the date.
+// Publication dates stay dc:date, modification dates are replaced by
+// dcterms:modified.
+var dateProperties = map[string]string{
+	"":             "dc:date",
+	"publication":  "dc:date",
+	"creation":     "dcterms:created",
+	"copyright":    "dcterms:dateCopyrighted",
+	"modification": "",
+}
+
+// identifierSchemes maps opf:scheme values to URN namespaces.
+var identifierSchemes = map[string]string{
+	"isbn": "isbn",
+	"issn": "issn",
+	"uuid": "uuid",
+	"doi":  "doi",
+}
+
+// Upgrade converts an EPUB 2 book to EPUB 3 in memory. Roles, sort keys and
+// identifier schemes become refining metas or URNs, dates are mapped by their
+// opf:event, a navigation document is generated from the NCX and the guide,
+// and the cover image and XHTML documents get their manifest properties.
+// The NCX and the guide are kept for older reading systems. The book is
+// unchanged if an error is returned. Write the result with
+// model.Book.WriteTo or SaveAs, or use UpgradeFile.
+func Upgrade(book *model.Book) error {
+	if !strings.HasPrefix(book.Version, "2") {
+		return fmt.Errorf("book is EPUB %s, not EPUB 2", book.Version)
+	}
+	converted := book.Copy()
+	if err := upgrade(converted); err != nil {
+		return err
+	}
+	*book = *converted
+	return nil
+}
+
+// UpgradeFile converts the EPUB 2 file input and writes the EPUB 3 book to
+// output, which must be a different file.
+func UpgradeFile(input string, output string) error {
+	if inputInfo, err := os.Stat(input); err == nil {
+		if outputInfo, err := os.Stat(output); err == nil && os.SameFile(inputInfo, outputInfo) {
+			return fmt.Errorf("cannot overwrite %s while reading it", input)
+		}
+	}
+	reader, err := zip.OpenReader(input)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	book, err := epub.OpenBook(&reader.Reader)
+	if err != nil {
+		return err
+	}
+	if err := Upgrade(book); err != nil {
+		return err
+	}
+	return book.SaveAs(output)
+}
+
+func upgrade(book *model.Book) error {
+	packagePath := book.Container.Rootfile.Path
+	data, err := book.ReadFile(packagePath)
+	if err != nil {
+		return err
+	}
+	document, err := opf.Parse(data)
+	if err != nil {
+		return err
+	}
+	toc, err := book.ReadTOC()
+	if err != nil {
+		return err
+	}
+	document.SetAttribute(document.Package, "version", "3.0")
+
+	navHref := freeHref(book, "nav", ".xhtml")
+	book.WriteFile(book.ItemPath(model.ManifestItem{Href: navHref}), builder.NavDocument(firstTitle(book), firstLanguage(book), "Table of Contents", toc, book.Landmarks))
+	navId := "nav"
+	if document.HasId(navId) {
+		navId = document.NewId(navId)
+	}
+	document.AppendManifest([]string{opf.Markup("item", "", "id", navId, "href", navHref, "media-type", "application/xhtml+xml", "properties", "nav")})
+
+	addManifestProperties(book, document)
+	upgradeIdentifiers(document)
+	opfPrefix, _ := document.Prefix(opf.NamespaceOpf)
+	for _, element := range document.Metadata {
+		for _, attr := range []string{"role", "file-as", "scheme"} {
+			if element.Attribute(opf.NamespaceOpf, attr) != "" {
+				document.RemoveAttribute(element, opfPrefix+":"+attr)
+			}
+		}
+	}
+	book.Metadata.Dates = upgradeDates(document, opfPrefix)
+	book.Metadata.MainId = upgradeIdentifier(book.Metadata.MainId)
+	// The metadata slices are shared with the original book and replaced
+	// instead of changed in place.
+	identifiers := append([]model.Identifier{}, model.Values(book.Metadata.Identifiers)...)
+	for i := range identifiers {
+		identifiers[i] = upgradeIdentifier(identifiers[i])
+	}
+	book.Metadata.Identifiers = &identifiers
+	if titles := model.Values(book.Metadata.Titles); len(titles) == 1 {
+		// EPUB 2 has no title types, the parser marks the first title "main".
+		titles = append([]model.Title{}, titles...)
+		titles[0].Type = ""
+		book.Metadata.Titles = &titles
+	}
+	book.WriteFile(packagePath, document.Bytes())
+
+	book.Version = "3.0"
+	data, err = epub_v3.WriteOpf(book, time.Now())
+	if err != nil {
+		return err
+	}
+	book.WriteFile(packagePath, data)
+	book.Metadata = model.Metadata{}
+	return epub_v3.ParseOpf(book)
+}
+
+// addManifestProperties adds cover-image to the item referenced by the cover
+// meta, and scripted, svg and mathml to XHTML documents using them.
+func addManifestProperties(book *model.Book, document *opf.Document) {
+	cover := ""
+	for _, element := range document.Metadata {
+		if element.Name.Local == "meta" && element.Attribute("", "name") == "cover" {
+			cover = element.Attribute("", "content")
+		}
+	}
+	for _, element := range document.Manifest {
+		item, ok := book.ManifestItem(element.Attribute("", "id"))
+		if !ok {
+			continue
+		}
+		properties := strings.Fields(element.Attribute("", "properties"))
+		added := false
+		if item.Id == cover && item.IsImage() {
+			properties = append(properties, "cover-image")
+			added = true
+		}
+		if item.IsXHTML() {
+			root, err := book.ReadItemHTML(item)
+			if err != nil {
+				continue
+			}
+			for _, property := range contentProperties(root) {
+				properties = append(properties, property)
+				added = true
+			}
+		}
+		if added {
+			document.SetAttribute(element, "properties", strings.Join(properties, " "))
+		}
+	}
+}
+
+// contentProperties returns the manifest properties EPUB 3 requires for the
+// elements of a document.
+func contentProperties(root *html.Node) []string {
+	found := map[string]bool{}
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			switch node.Data {
+			case "script":
+				found["scripted"] = true
+			case "svg":
+				found["svg"] = true
+			case "math":
+				found["mathml"] = true
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+	var properties []string
+	for _, property := range []string{"scripted", "svg", "mathml"} {
+		if found[property] {
+			properties = append(properties, property)
+		}
+	}
+	return properties
+}
+
+// upgradeDates replaces dates with an opf:event by their EPUB 3 meta and
+// returns the remaining dc:date values.
+func upgradeDates(document *opf.Document, opfPrefix string) *[]string {
+	var dates []string
+	for _, element := range document.Metadata {
+		if !element.Is(opf.NamespaceDC, "date") {
+			continue
+		}
+		event := strings.ToLower(strings.TrimSpace(element.Attribute(opf.NamespaceOpf, "event")))
+		property, ok := dateProperties[event]
+		if !ok {
+			property = "dcterms:date"
+		}
+		if property == "dc:date" && len(dates) > 0 {
+			property = "dcterms:date"
+		}
+		switch property {
+		case "dc:date":
+			dates = append(dates, element.Text)
+			if event != "" {
+				document.RemoveAttribute(element, opfPrefix+":event")
+			}
+		case "":
+			document.Remove(element)
+		default:
+			document.InsertBefore(element, []string{opf.Markup("meta", element.Text, "property", property)})
+			document.Remove(element)
+		}
+	}
+	return &dates
+}
+
+// upgradeIdentifiers writes identifiers with an opf:scheme as URNs in the
+// package document. Schemes without a URN namespace are kept in an
+// identifier-type meta refining the identifier, which gets an id if needed.
+func upgradeIdentifiers(document *opf.Document) {
+	for _, element := range document.Metadata {
+		scheme := element.Attribute(opf.NamespaceOpf, "scheme")
+		if !element.Is(opf.NamespaceDC, "identifier") || scheme == "" {
+			continue
+		}
+		identifier := upgradeIdentifier(model.Identifier{Id: element.Text, Scheme: scheme})
+		switch identifier.Scheme {
+		case "urn":
+			document.SetText(element, "urn:"+identifier.Id)
+		case "":
+			id := element.Attribute("", "id")
+			if id == "" {
+				id = document.NewId("identifier")
+				document.SetAttribute(element, "id", id)
+			}
+			document.InsertAfter(element, []string{opf.Markup("meta", scheme, "refines", "#"+id, "property", "identifier-type")})
+		}
+	}
+}
+
+// upgradeIdentifier turns identifiers with an opf:scheme into URNs, e.g.
+// ISBN 9780000000000 into urn:isbn:9780000000000. Other schemes are left to
+// the identifier-type meta written by upgradeIdentifiers.
+func upgradeIdentifier(identifier model.Identifier) model.Identifier {
+	if strings.Contains(identifier.Id, ":") {
+		scheme, id, _ := strings.Cut(identifier.Id, ":")
+		return model.Identifier{Id: id, Scheme: scheme}
+	}
+	if namespace, ok := identifierSchemes[strings.ToLower(identifier.Scheme)]; ok {
+		return model.Identifier{Id: namespace + ":" + identifier.Id, Scheme: "urn"}
+	}
+	return model.Identifier{Id: identifier.Id}
+}
+
+// freeHref returns a file name next to the package document that is not used
+// yet.
+func freeHref(book *model.Book, name string, extension string) string {
+	href := name + extension
+	for i := 2; ; i++ {
+		if _, ok := book.ItemSize(model.ManifestItem{Href: href}); !ok {
+			return href
+		}
+		href = fmt.Sprintf("%s-%d%s", name, i, extension)
+	}
+}
+
+func firstTitle(book *model.Book) string {
+	if titles := model.Values(book.Metadata.Titles); len(titles) > 0 {
+		return titles[0].Title
+	}
+	return ""
+}
+
+func firstLanguage(book *model.Book) string {
+	if languages := model.Values(book.Metadata.Languages); len(languages) > 0 {
+		return languages[0].String()
+	}
+	return "en"
+}
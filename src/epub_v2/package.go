@@ -3,43 +3,69 @@ package epub_v2
 import (
 	"encoding/xml"
 	"github.com/mathieu-keller/epub-parser/model"
+	"github.com/mathieu-keller/epub-parser/roles"
+	"github.com/mathieu-keller/epub-parser/sortkey"
 )
 
-func getTitles(metaData []DefaultAttributes) *[]model.Title {
+func getTitles(metaData []DefaultAttributes, metas []Meta, packageLanguage model.Language, packageDir model.Direction, defaultLanguage model.Language) *[]model.Title {
 	titles := make([]model.Title, len(metaData))
 	for i, title := range metaData {
 		titles[i] = model.Title{
 			Title:    title.Text,
-			Language: title.Lang,
-			Type:     "main",
-			FileAs:   title.Text,
+			Language: model.ParseLanguage(title.Lang).Or(packageLanguage),
+			Dir:      packageDir,
 		}
+		if i == 0 {
+			titles[i].Type = "main"
+			if titleSort := getMetaContent(metas, "calibre:title_sort"); titleSort != "" {
+				titles[i].FileAs = titleSort
+				titles[i].FileAsOrigin = model.OriginBook
+				continue
+			}
+		}
+		language := titles[i].Language.Or(defaultLanguage)
+		titles[i].FileAs = sortkey.Title(title.Text, language.String())
+		titles[i].FileAsOrigin = model.OriginGenerated
 	}
 	return &titles
 }
 
-func getLanguages(metaData []ID) *[]string {
-	languages := make([]string, len(metaData))
+func getMetaContent(metas []Meta, name string) string {
+	for _, meta := range metas {
+		if meta.Name == name {
+			if meta.Content != "" {
+				return meta.Content
+			}
+			return meta.Text
+		}
+	}
+	return ""
+}
+
+func getLanguages(metaData []ID) *[]model.Language {
+	languages := make([]model.Language, len(metaData))
 	for i, language := range metaData {
-		languages[i] = language.Text
+		languages[i] = model.ParseLanguage(language.Text)
 	}
 	return &languages
 }
 
-func getCreators(metaData []Creator) *[]model.Creator {
+func getCreators(metaData []Creator, packageLanguage model.Language, packageDir model.Direction) *[]model.Creator {
 	if metaData != nil {
 		creators := make([]model.Creator, len(metaData))
 		for i, creator := range metaData {
-			role, ok := model.Relator[creator.Role]
-			if !ok && creator.Role != "" {
-				role = "unknown"
-			}
 			creators[i] = model.Creator{
 				Name:     creator.Text,
 				FileAs:   creator.FileAs,
-				RawRole:  creator.Role,
-				Language: creator.Lang,
-				Role:     role,
+				Language: model.ParseLanguage(creator.Lang).Or(packageLanguage),
+				Dir:      packageDir,
+			}
+			roles.Resolve(roles.SchemeMarcRelators, creator.Role).Apply(&creators[i])
+			if creators[i].FileAs == "" {
+				creators[i].FileAs = sortkey.Name(creator.Text)
+				creators[i].FileAsOrigin = model.OriginGenerated
+			} else {
+				creators[i].FileAsOrigin = model.OriginBook
 			}
 		}
 		return &creators
@@ -47,13 +73,32 @@ func getCreators(metaData []Creator) *[]model.Creator {
 	return nil
 }
 
-func getDefaultAttributes(metaData []DefaultAttributes) *[]model.DefaultAttributes {
+func getAccessibility(metas []Meta) *model.Accessibility {
+	accessibility := model.Accessibility{}
+	found := false
+	for _, meta := range metas {
+		value := meta.Content
+		if value == "" {
+			value = meta.Text
+		}
+		if accessibility.Add(meta.Name, value) {
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return &accessibility
+}
+
+func getDefaultAttributes(metaData []DefaultAttributes, packageLanguage model.Language, packageDir model.Direction) *[]model.DefaultAttributes {
 	if metaData != nil {
 		defaultAttributes := make([]model.DefaultAttributes, len(metaData))
 		for i, defaultAttribute := range metaData {
 			defaultAttributes[i] = model.DefaultAttributes{
 				Text:     defaultAttribute.Text,
-				Language: defaultAttribute.Lang,
+				Language: model.ParseLanguage(defaultAttribute.Lang).Or(packageLanguage),
+				Dir:      packageDir,
 			}
 		}
 		return &defaultAttributes
@@ -72,6 +117,53 @@ func getDate(metaData []Date) *[]string {
 	return nil
 }
 
+func getManifest(manifestData *Manifest) *[]model.ManifestItem {
+	if manifestData == nil {
+		return nil
+	}
+	items := model.Values(manifestData.Item)
+	manifest := make([]model.ManifestItem, len(items))
+	for i, item := range items {
+		manifest[i] = model.ManifestItem{
+			Id:                item.Id,
+			Href:              item.Href,
+			MediaType:         item.MediaType,
+			Fallback:          item.Fallback,
+			FallbackStyle:     item.FallbackStyle,
+			RequiredNamespace: item.RequiredNamespace,
+			RequiredModules:   item.RequiredModules,
+		}
+	}
+	return &manifest
+}
+
+func getSpine(spineData *Spine, rendition model.Rendition) *[]model.SpineItem {
+	if spineData == nil {
+		return nil
+	}
+	itemrefs := model.Values(spineData.Itemref)
+	spine := make([]model.SpineItem, len(itemrefs))
+	for i, itemref := range itemrefs {
+		spine[i] = model.SpineItem{
+			IdRef:     itemref.IdRef,
+			Linear:    itemref.Linear != "no",
+			Rendition: rendition,
+		}
+	}
+	return &spine
+}
+
+func getGuide(guideData *Guide) []model.Landmark {
+	if guideData == nil {
+		return nil
+	}
+	var landmarks []model.Landmark
+	for _, reference := range model.Values(guideData.Reference) {
+		landmarks = append(landmarks, model.NewGuideLandmark(reference.Type, reference.Title, reference.Href))
+	}
+	return landmarks
+}
+
 func ParseOpf(book *model.Book) error {
 	opf := Package{}
 	err := book.ReadXML(book.Container.Rootfile.Path, &opf)
@@ -79,8 +171,8 @@ func ParseOpf(book *model.Book) error {
 		return err
 	}
 
-	identifiers := make([]model.Identifier, len(*opf.Metadata.Identifier))
-	for i, identifier := range *opf.Metadata.Identifier {
+	identifiers := make([]model.Identifier, len(model.Values(opf.Metadata.Identifier)))
+	for i, identifier := range model.Values(opf.Metadata.Identifier) {
 		identifiers[i] = model.Identifier{
 			Id:     identifier.Text,
 			Scheme: identifier.Scheme,
@@ -94,14 +186,32 @@ func ParseOpf(book *model.Book) error {
 	}
 	book.Metadata.Identifiers = &identifiers
 
-	book.Metadata.Titles = getTitles(*opf.Metadata.Title)
-	book.Metadata.Languages = getLanguages(*opf.Metadata.Language)
-	book.Metadata.Creators = getCreators(*opf.Metadata.Creator)
-	book.Metadata.Contributors = getCreators(*opf.Metadata.Contributor)
-	book.Metadata.Publishers = getDefaultAttributes(*opf.Metadata.Publisher)
-	book.Metadata.Subjects = getDefaultAttributes(*opf.Metadata.Subject)
-	book.Metadata.Descriptions = getDefaultAttributes(*opf.Metadata.Description)
-	book.Metadata.Dates = getDate(*opf.Metadata.Date)
+	metas := model.Values(opf.Metadata.Meta)
+	book.Metadata.Languages = getLanguages(model.Values(opf.Metadata.Language))
+	packageLanguage := model.ParseLanguage(opf.Lang)
+	packageDir := model.ParseDirection(opf.Dir)
+	defaultLanguage := packageLanguage
+	if len(*book.Metadata.Languages) > 0 {
+		defaultLanguage = packageLanguage.Or((*book.Metadata.Languages)[0])
+	}
+	book.Metadata.Titles = getTitles(model.Values(opf.Metadata.Title), metas, packageLanguage, packageDir, defaultLanguage)
+	book.Metadata.Creators = getCreators(model.Values(opf.Metadata.Creator), packageLanguage, packageDir)
+	book.Metadata.Contributors = getCreators(model.Values(opf.Metadata.Contributor), packageLanguage, packageDir)
+	book.Metadata.Publishers = getDefaultAttributes(model.Values(opf.Metadata.Publisher), packageLanguage, packageDir)
+	book.Metadata.Subjects = getDefaultAttributes(model.Values(opf.Metadata.Subject), packageLanguage, packageDir)
+	book.Metadata.Descriptions = getDefaultAttributes(model.Values(opf.Metadata.Description), packageLanguage, packageDir)
+	book.Metadata.Dates = getDate(model.Values(opf.Metadata.Date))
+	book.Manifest = getManifest(opf.Manifest)
+	// The display options are an optional vendor file, a broken one must not
+	// make the book unreadable.
+	displayOptions, _ := book.ReadDisplayOptions()
+	book.Rendition = displayOptions.WithDefaults()
+	book.Spine = getSpine(opf.Spine, book.Rendition)
+	if opf.Spine != nil {
+		book.PageProgressionDirection = model.ParseDirection(opf.Spine.PageProgressionDirection)
+	}
+	book.Metadata.Accessibility = getAccessibility(metas)
+	book.Landmarks = getGuide(opf.Guide)
 
 	return err
 }
@@ -110,6 +220,8 @@ type Package struct {
 	XMLName          xml.Name  `xml:"package"`
 	Metadata         *Metadata `xml:"metadata"`
 	Manifest         *Manifest `xml:"manifest"`
+	Spine            *Spine    `xml:"spine"`
+	Guide            *Guide    `xml:"guide"`
 	Version          string    `xml:"version,attr"`
 	UniqueIdentifier string    `xml:"unique-identifier,attr"`
 	ID               string    `xml:"id,attr,omitempty"`
@@ -169,10 +281,22 @@ type Identifier struct {
 }
 
 type Meta struct {
-	Text   string `xml:",chardata"`
-	Lang   string `xml:"lang,attr,omitempty"`
-	Name   string `xml:"name,attr"`
-	Scheme string `xml:"scheme,attr,omitempty"`
+	Text    string `xml:",chardata"`
+	Lang    string `xml:"lang,attr,omitempty"`
+	Name    string `xml:"name,attr"`
+	Content string `xml:"content,attr,omitempty"`
+	Scheme  string `xml:"scheme,attr,omitempty"`
+}
+
+type Spine struct {
+	Toc                      string     `xml:"toc,attr,omitempty"`
+	PageProgressionDirection string     `xml:"page-progression-direction,attr,omitempty"`
+	Itemref                  *[]Itemref `xml:"itemref"`
+}
+
+type Itemref struct {
+	IdRef  string `xml:"idref,attr"`
+	Linear string `xml:"linear,attr,omitempty"`
 }
 
 type Manifest struct {
@@ -189,3 +313,13 @@ type Item struct {
 	RequiredModules   string `xml:"required-modules,attr,omitempty"`
 	RequiredNamespace string `xml:"required-namespace,attr,omitempty"`
 }
+
+type Guide struct {
+	Reference *[]Reference `xml:"reference"`
+}
+
+type Reference struct {
+	Type  string `xml:"type,attr"`
+	Title string `xml:"title,attr,omitempty"`
+	Href  string `xml:"href,attr"`
+}
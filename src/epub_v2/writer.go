@@ -0,0 +1,224 @@
+package epub_v2
+
+import (
+	"reflect"
+
+	"github.com/mathieu-keller/epub-parser/model"
+	"github.com/mathieu-keller/epub-parser/opf"
+	"github.com/mathieu-keller/epub-parser/roles"
+)
+
+// WriteOpf returns the package document with book.Metadata written back
+// into it. Only kinds of metadata that differ from the package document are
+// rewritten; unknown elements, namespaces and the order of everything else
+// are kept. Roles, sort keys and identifier schemes are written as opf:role,
+// opf:file-as and opf:scheme attributes, the sort key of the first title as
+// calibre:title_sort meta.
+func WriteOpf(book *model.Book) ([]byte, error) {
+	data, err := book.ReadFile(book.Container.Rootfile.Path)
+	if err != nil {
+		return nil, err
+	}
+	document, err := opf.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	original := *book
+	original.Metadata = model.Metadata{}
+	if err := ParseOpf(&original); err != nil {
+		return nil, err
+	}
+	writer := &metadataWriter{
+		document:        document,
+		packageLanguage: model.ParseLanguage(document.Lang),
+	}
+	before, after := original.Metadata, book.Metadata
+	if before.MainId != after.MainId || !equal(before.Identifiers, after.Identifiers) {
+		writer.replace("identifier", writer.identifiers(after))
+	}
+	if !equal(before.Titles, after.Titles) {
+		writer.replace("title", writer.titles(model.Values(after.Titles)))
+		writer.titleSort(model.Values(after.Titles))
+	}
+	if !equal(before.Languages, after.Languages) {
+		writer.replace("language", writer.languages(model.Values(after.Languages)))
+	}
+	if !equal(before.Creators, after.Creators) {
+		writer.replace("creator", writer.creators("creator", model.Values(after.Creators)))
+	}
+	if !equal(before.Contributors, after.Contributors) {
+		writer.replace("contributor", writer.creators("contributor", model.Values(after.Contributors)))
+	}
+	if !equal(before.Publishers, after.Publishers) {
+		writer.replace("publisher", writer.defaultAttributes("publisher", model.Values(after.Publishers)))
+	}
+	if !equal(before.Subjects, after.Subjects) {
+		writer.replace("subject", writer.defaultAttributes("subject", model.Values(after.Subjects)))
+	}
+	if !equal(before.Descriptions, after.Descriptions) {
+		writer.replace("description", writer.defaultAttributes("description", model.Values(after.Descriptions)))
+	}
+	if !equal(before.Dates, after.Dates) {
+		writer.replace("date", writer.dates(model.Values(after.Dates)))
+	}
+	return document.Bytes(), nil
+}
+
+func equal[T any](before *[]T, after *[]T) bool {
+	return reflect.DeepEqual(model.Values(before), model.Values(after)) || len(model.Values(before))+len(model.Values(after)) == 0
+}
+
+type metadataWriter struct {
+	document        *opf.Document
+	packageLanguage model.Language
+}
+
+func (writer *metadataWriter) replace(local string, markup []string) {
+	writer.document.Replace(opf.NamespaceDC, func(element opf.Element) bool {
+		return element.Is(opf.NamespaceDC, local)
+	}, markup)
+}
+
+// element serializes a dc element. opfAttrs are written with the opf prefix,
+// the dc and opf namespaces are declared on the element if the document does
+// not declare them.
+func (writer *metadataWriter) element(local string, text string, attrs []string, opfAttrs ...string) string {
+	name, declarations := writer.document.QualifiedName(opf.NamespaceDC, local, "dc")
+	for i := 0; i+1 < len(opfAttrs); i += 2 {
+		if opfAttrs[i+1] == "" {
+			continue
+		}
+		attr, declaration := writer.document.QualifiedName(opf.NamespaceOpf, opfAttrs[i], "opf")
+		if declaration != nil && !contains(declarations, declaration[0]) {
+			declarations = append(declarations, declaration...)
+		}
+		attrs = append(attrs, attr, opfAttrs[i+1])
+	}
+	return opf.Markup(name, text, append(declarations, attrs...)...)
+}
+
+func contains(attrs []string, name string) bool {
+	for i := 0; i < len(attrs); i += 2 {
+		if attrs[i] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// language returns the xml:lang attribute if it differs from the package
+// element.
+func (writer *metadataWriter) language(language model.Language) []string {
+	if language.IsEmpty() || language.Equal(writer.packageLanguage) {
+		return nil
+	}
+	return []string{"xml:lang", rawLanguage(language)}
+}
+
+func rawLanguage(language model.Language) string {
+	if language.Raw != "" {
+		return language.Raw
+	}
+	return language.String()
+}
+
+func (writer *metadataWriter) identifiers(metadata model.Metadata) []string {
+	identifiers := model.Values(metadata.Identifiers)
+	found := false
+	for _, identifier := range identifiers {
+		found = found || identifier == metadata.MainId
+	}
+	if !found && metadata.MainId.Id != "" {
+		identifiers = append([]model.Identifier{metadata.MainId}, identifiers...)
+	}
+	var markup []string
+	for _, identifier := range identifiers {
+		id := ""
+		if identifier == metadata.MainId {
+			id = writer.document.UniqueIdentifier
+		}
+		markup = append(markup, writer.element("identifier", identifier.Id, []string{"id", id}, "scheme", identifier.Scheme))
+	}
+	return markup
+}
+
+func (writer *metadataWriter) titles(titles []model.Title) []string {
+	var markup []string
+	for _, title := range titles {
+		markup = append(markup, writer.element("title", title.Title, writer.language(title.Language)))
+	}
+	return markup
+}
+
+// titleSort replaces the calibre:title_sort meta with the sort key of the
+// first title, or removes it if the sort key was generated.
+func (writer *metadataWriter) titleSort(titles []model.Title) {
+	var markup []string
+	if len(titles) > 0 && titles[0].FileAs != "" && titles[0].FileAsOrigin != model.OriginGenerated {
+		markup = append(markup, opf.Markup("meta", "", "name", "calibre:title_sort", "content", titles[0].FileAs))
+	}
+	writer.document.Replace(opf.NamespaceOpf, func(element opf.Element) bool {
+		return element.Name.Local == "meta" && element.Attribute("", "name") == "calibre:title_sort"
+	}, markup)
+}
+
+func (writer *metadataWriter) languages(languages []model.Language) []string {
+	var markup []string
+	for _, language := range languages {
+		markup = append(markup, writer.element("language", rawLanguage(language), nil))
+	}
+	return markup
+}
+
+func (writer *metadataWriter) creators(local string, creators []model.Creator) []string {
+	var markup []string
+	for _, creator := range creators {
+		fileAs := ""
+		if creator.FileAsOrigin != model.OriginGenerated {
+			fileAs = creator.FileAs
+		}
+		markup = append(markup, writer.element(local, creator.Name, writer.language(creator.Language), "role", roleCode(creator), "file-as", fileAs))
+	}
+	return markup
+}
+
+// roleCode returns the MARC relator code of a creator. Creators added
+// without a code are looked up by their English label.
+func roleCode(creator model.Creator) string {
+	if creator.RawRole != "" {
+		return creator.RawRole
+	}
+	if creator.Role == "" || creator.Role == roles.Unknown {
+		return ""
+	}
+	code, _ := model.Relator.Code(creator.Role)
+	return code
+}
+
+func (writer *metadataWriter) defaultAttributes(local string, attributes []model.DefaultAttributes) []string {
+	var markup []string
+	for _, attribute := range attributes {
+		markup = append(markup, writer.element(local, attribute.Text, writer.language(attribute.Language)))
+	}
+	return markup
+}
+
+// dates keeps the opf:event of the date element at the same position, the
+// model has no events.
+func (writer *metadataWriter) dates(dates []string) []string {
+	var events []string
+	for _, element := range writer.document.Metadata {
+		if element.Is(opf.NamespaceDC, "date") {
+			events = append(events, element.Attribute(opf.NamespaceOpf, "event"))
+		}
+	}
+	var markup []string
+	for i, date := range dates {
+		event := ""
+		if i < len(events) {
+			event = events[i]
+		}
+		markup = append(markup, writer.element("date", date, nil, "event", event))
+	}
+	return markup
+}
@@ -3,6 +3,8 @@ package epub_v3
 import (
 	"encoding/xml"
 	"github.com/mathieu-keller/epub-parser/model"
+	"github.com/mathieu-keller/epub-parser/roles"
+	"github.com/mathieu-keller/epub-parser/sortkey"
 	"strings"
 )
 
@@ -48,42 +50,77 @@ func getMetaMap(metaData []Meta) *map[string]map[string]Meta {
 	return &metaMap
 }
 
-func getTitles(metaData []DefaultAttributes, metaMap map[string]map[string]Meta) *[]model.Title {
+func getTitles(metaData []DefaultAttributes, metaMap map[string]map[string]Meta, metas []Meta, packageLanguage model.Language, packageDir model.Direction, defaultLanguage model.Language) *[]model.Title {
 	titles := make([]model.Title, len(metaData))
 	for i, title := range metaData {
 		fileAs := getMetadata(metaMap, title.Id, "file-as")
 		titleType := getMetadata(metaMap, title.Id, "title-type")
 		titles[i] = model.Title{
-			Title:    title.Text,
-			Language: title.Lang,
-			Type:     titleType,
-			FileAs:   fileAs,
+			Title:        title.Text,
+			Language:     model.ParseLanguage(title.Lang).Or(packageLanguage),
+			Dir:          model.ParseDirection(title.Dir).Or(packageDir),
+			Type:         titleType,
+			FileAs:       fileAs,
+			FileAsOrigin: model.OriginBook,
 		}
+		if fileAs != "" {
+			continue
+		}
+		if i == 0 {
+			if titleSort := getMetaContent(metas, "calibre:title_sort"); titleSort != "" {
+				titles[i].FileAs = titleSort
+				continue
+			}
+		}
+		language := titles[i].Language.Or(defaultLanguage)
+		titles[i].FileAs = sortkey.Title(title.Text, language.String())
+		titles[i].FileAsOrigin = model.OriginGenerated
 	}
 	return &titles
 }
 
-func getLanguages(metaData []ID) *[]string {
-	languages := make([]string, len(metaData))
+func getMetaContent(metas []Meta, name string) string {
+	for _, meta := range metas {
+		if meta.Refines != "" {
+			continue
+		}
+		if meta.Name == name {
+			return meta.Content
+		}
+		if meta.Property == name {
+			return meta.Text
+		}
+	}
+	return ""
+}
+
+func getLanguages(metaData []ID) *[]model.Language {
+	languages := make([]model.Language, len(metaData))
 	for i, language := range metaData {
-		languages[i] = language.Text
+		languages[i] = model.ParseLanguage(language.Text)
 	}
 	return &languages
 }
 
-func getCreators(metaData []DefaultAttributes, metaMap map[string]map[string]Meta) *[]model.Creator {
+func getCreators(metaData []DefaultAttributes, metaMap map[string]map[string]Meta, packageLanguage model.Language, packageDir model.Direction) *[]model.Creator {
 	if metaData != nil {
 		creators := make([]model.Creator, len(metaData))
 		for i, creator := range metaData {
 			fileAs := getMetadata(metaMap, creator.Id, "file-as")
-			rawRole := getMetadata(metaMap, creator.Id, "role")
-			role := getRole(metaMap, creator, rawRole)
 			creators[i] = model.Creator{
 				Name:     creator.Text,
 				FileAs:   fileAs,
-				RawRole:  rawRole,
-				Language: creator.Lang,
-				Role:     role,
+				Language: model.ParseLanguage(creator.Lang).Or(packageLanguage),
+				Dir:      model.ParseDirection(creator.Dir).Or(packageDir),
+			}
+			rawRole := getMetadata(metaMap, creator.Id, "role")
+			scheme := getMetadataSchema(metaMap, creator.Id, "role")
+			roles.Resolve(scheme, rawRole).Apply(&creators[i])
+			if creators[i].FileAs == "" {
+				creators[i].FileAs = sortkey.Name(creator.Text)
+				creators[i].FileAsOrigin = model.OriginGenerated
+			} else {
+				creators[i].FileAsOrigin = model.OriginBook
 			}
 		}
 		return &creators
@@ -91,22 +128,39 @@ func getCreators(metaData []DefaultAttributes, metaMap map[string]map[string]Met
 	return nil
 }
 
-func getRole(metaMap map[string]map[string]Meta, creator DefaultAttributes, rawRole string) string {
-	scheme := getMetadataSchema(metaMap, creator.Id, "role")
-	role := "unknown"
-	if scheme == "marc:relators" {
-		role, _ = model.Relator[rawRole]
+func getAccessibility(metas []Meta, links []Link) *model.Accessibility {
+	accessibility := model.Accessibility{}
+	found := false
+	for _, meta := range metas {
+		property, value := meta.Property, meta.Text
+		if property == "" {
+			property, value = meta.Name, meta.Content
+		}
+		if accessibility.Add(property, value) {
+			found = true
+		}
+	}
+	for _, link := range links {
+		for _, rel := range strings.Fields(link.Rel) {
+			if accessibility.Add(rel, link.Href) {
+				found = true
+			}
+		}
 	}
-	return role
+	if !found {
+		return nil
+	}
+	return &accessibility
 }
 
-func getDefaultAttributes(metaData []DefaultAttributes) *[]model.DefaultAttributes {
+func getDefaultAttributes(metaData []DefaultAttributes, packageLanguage model.Language, packageDir model.Direction) *[]model.DefaultAttributes {
 	if metaData != nil {
 		defaultAttributes := make([]model.DefaultAttributes, len(metaData))
 		for i, defaultAttribute := range metaData {
 			defaultAttributes[i] = model.DefaultAttributes{
 				Text:     defaultAttribute.Text,
-				Language: defaultAttribute.Lang,
+				Language: model.ParseLanguage(defaultAttribute.Lang).Or(packageLanguage),
+				Dir:      model.ParseDirection(defaultAttribute.Dir).Or(packageDir),
 			}
 		}
 		return &defaultAttributes
@@ -125,19 +179,88 @@ func getDates(metaData []ID) *[]string {
 	return nil
 }
 
+func getRendition(metas []Meta) model.Rendition {
+	rendition := model.Rendition{}
+	for _, meta := range metas {
+		if meta.Refines == "" {
+			rendition.Set(meta.Property, meta.Text)
+		}
+	}
+	return rendition
+}
+
+func getMediaOverlays(metas []Meta) model.MediaOverlays {
+	overlays := model.MediaOverlays{}
+	for _, meta := range metas {
+		if strings.HasPrefix(meta.Property, "media:") {
+			overlays.Set(meta.Property, strings.TrimPrefix(meta.Refines, "#"), meta.Text)
+		}
+	}
+	return overlays
+}
+
+func getManifest(manifestData *Manifest) *[]model.ManifestItem {
+	if manifestData == nil {
+		return nil
+	}
+	items := model.Values(manifestData.Item)
+	manifest := make([]model.ManifestItem, len(items))
+	for i, item := range items {
+		manifest[i] = model.ManifestItem{
+			Id:           item.Id,
+			Href:         item.Href,
+			MediaType:    item.MediaType,
+			Fallback:     item.Fallback,
+			MediaOverlay: item.MediaOverlay,
+			Properties:   strings.Fields(item.Properties),
+		}
+	}
+	return &manifest
+}
+
+func getSpine(spineData *Spine, rendition model.Rendition) *[]model.SpineItem {
+	if spineData == nil {
+		return nil
+	}
+	itemrefs := model.Values(spineData.Itemref)
+	spine := make([]model.SpineItem, len(itemrefs))
+	for i, itemref := range itemrefs {
+		spine[i] = model.SpineItem{
+			Id:         itemref.Id,
+			IdRef:      itemref.IdRef,
+			Linear:     itemref.Linear != "no",
+			Properties: strings.Fields(itemref.Properties),
+		}
+		spine[i].Rendition = rendition.Override(spine[i].Properties)
+	}
+	return &spine
+}
+
+func getGuide(guideData *Guide) []model.Landmark {
+	if guideData == nil {
+		return nil
+	}
+	var landmarks []model.Landmark
+	for _, reference := range model.Values(guideData.Reference) {
+		landmarks = append(landmarks, model.NewGuideLandmark(reference.Type, reference.Title, reference.Href))
+	}
+	return landmarks
+}
+
 func ParseOpf(book *model.Book) error {
 	opf := Package{}
 	err := book.ReadXML(book.Container.Rootfile.Path, &opf)
 	if err != nil {
 		return err
 	}
-	metaMap := getMetaMap(*opf.Metadata.Meta)
+	metaMap := getMetaMap(model.Values(opf.Metadata.Meta))
 
-	identifiers := make([]model.Identifier, len(*opf.Metadata.Identifier))
-	for i, identifier := range *opf.Metadata.Identifier {
-		parts := strings.Split(identifier.Text, ":")
-		scheme := parts[0]
-		id := parts[1]
+	identifiers := make([]model.Identifier, len(model.Values(opf.Metadata.Identifier)))
+	for i, identifier := range model.Values(opf.Metadata.Identifier) {
+		scheme, id, found := strings.Cut(identifier.Text, ":")
+		if !found {
+			scheme, id = "", identifier.Text
+		}
 		identifiers[i] = model.Identifier{
 			Id:     id,
 			Scheme: scheme,
@@ -151,21 +274,44 @@ func ParseOpf(book *model.Book) error {
 	}
 	book.Metadata.Identifiers = &identifiers
 
-	book.Metadata.Titles = getTitles(*opf.Metadata.Title, *metaMap)
-	book.Metadata.Languages = getLanguages(*opf.Metadata.Language)
-	book.Metadata.Creators = getCreators(*opf.Metadata.Creator, *metaMap)
-	book.Metadata.Contributors = getCreators(*opf.Metadata.Contributor, *metaMap)
-	book.Metadata.Publishers = getDefaultAttributes(*opf.Metadata.Publisher)
-	book.Metadata.Subjects = getDefaultAttributes(*opf.Metadata.Subject)
-	book.Metadata.Descriptions = getDefaultAttributes(*opf.Metadata.Description)
-	book.Metadata.Dates = getDates(*opf.Metadata.Date)
-	return err
+	book.Metadata.Languages = getLanguages(model.Values(opf.Metadata.Language))
+	packageLanguage := model.ParseLanguage(opf.Lang)
+	packageDir := model.ParseDirection(opf.Dir)
+	defaultLanguage := packageLanguage
+	if len(*book.Metadata.Languages) > 0 {
+		defaultLanguage = packageLanguage.Or((*book.Metadata.Languages)[0])
+	}
+	book.Metadata.Titles = getTitles(model.Values(opf.Metadata.Title), *metaMap, model.Values(opf.Metadata.Meta), packageLanguage, packageDir, defaultLanguage)
+	book.Metadata.Creators = getCreators(model.Values(opf.Metadata.Creator), *metaMap, packageLanguage, packageDir)
+	book.Metadata.Contributors = getCreators(model.Values(opf.Metadata.Contributor), *metaMap, packageLanguage, packageDir)
+	book.Metadata.Publishers = getDefaultAttributes(model.Values(opf.Metadata.Publisher), packageLanguage, packageDir)
+	book.Metadata.Subjects = getDefaultAttributes(model.Values(opf.Metadata.Subject), packageLanguage, packageDir)
+	book.Metadata.Descriptions = getDefaultAttributes(model.Values(opf.Metadata.Description), packageLanguage, packageDir)
+	book.Metadata.Dates = getDates(model.Values(opf.Metadata.Date))
+	book.Manifest = getManifest(opf.Manifest)
+	book.MediaOverlays = getMediaOverlays(model.Values(opf.Metadata.Meta))
+	// The display options are an optional vendor file, a broken one must not
+	// make the book unreadable.
+	displayOptions, _ := book.ReadDisplayOptions()
+	book.Rendition = getRendition(model.Values(opf.Metadata.Meta)).Or(displayOptions).WithDefaults()
+	book.Spine = getSpine(opf.Spine, book.Rendition)
+	if opf.Spine != nil {
+		book.PageProgressionDirection = model.ParseDirection(opf.Spine.PageProgressionDirection)
+	}
+	book.Metadata.Accessibility = getAccessibility(model.Values(opf.Metadata.Meta), model.Values(opf.Metadata.Link))
+	// Landmarks are optional, a nav document that cannot be read leaves the
+	// ones of the guide.
+	navLandmarks, _ := book.ReadNavLandmarks()
+	book.Landmarks = model.MergeLandmarks(navLandmarks, getGuide(opf.Guide))
+	return nil
 }
 
 type Package struct {
 	XMLName          xml.Name  `xml:"package"`
 	Metadata         *Metadata `xml:"metadata"`
 	Manifest         *Manifest `xml:"manifest"`
+	Spine            *Spine    `xml:"spine"`
+	Guide            *Guide    `xml:"guide"`
 	Version          string    `xml:"version,attr"`
 	UniqueIdentifier string    `xml:"unique-identifier,attr"`
 	ID               string    `xml:"id,attr,omitempty"`
@@ -186,6 +332,20 @@ type DefaultAttributes struct {
 	Text string `xml:",chardata"`
 }
 
+type Spine struct {
+	Id                       string     `xml:"id,attr,omitempty"`
+	Toc                      string     `xml:"toc,attr,omitempty"`
+	PageProgressionDirection string     `xml:"page-progression-direction,attr,omitempty"`
+	Itemref                  *[]Itemref `xml:"itemref"`
+}
+
+type Itemref struct {
+	IdRef      string `xml:"idref,attr"`
+	Id         string `xml:"id,attr,omitempty"`
+	Linear     string `xml:"linear,attr,omitempty"`
+	Properties string `xml:"properties,attr,omitempty"`
+}
+
 type Manifest struct {
 	Id   string  `xml:"id,attr,omitempty"`
 	Item *[]Item `xml:"item"`
@@ -238,3 +398,13 @@ type Meta struct {
 	Content  string `xml:"content,attr,omitempty"` //deprecated
 	Text     string `xml:",chardata"`
 }
+
+type Guide struct {
+	Reference *[]Reference `xml:"reference"`
+}
+
+type Reference struct {
+	Type  string `xml:"type,attr"`
+	Title string `xml:"title,attr,omitempty"`
+	Href  string `xml:"href,attr"`
+}
@@ -0,0 +1,249 @@
+package epub_v3
+
+import (
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/mathieu-keller/epub-parser/model"
+	"github.com/mathieu-keller/epub-parser/opf"
+	"github.com/mathieu-keller/epub-parser/roles"
+)
+
+const modifiedFormat = "2006-01-02T15:04:05Z"
+
+// WriteOpf returns the package document with book.Metadata written back
+// into it. Only entries that differ from the package document are
+// rewritten; they keep their id and the metas refining them that the writer
+// does not generate. Identifiers are rewritten as a whole. Unknown elements,
+// namespaces and the order of everything else are kept. dcterms:modified is
+// set to modified.
+func WriteOpf(book *model.Book, modified time.Time) ([]byte, error) {
+	data, err := book.ReadFile(book.Container.Rootfile.Path)
+	if err != nil {
+		return nil, err
+	}
+	document, err := opf.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	original := *book
+	original.Metadata = model.Metadata{}
+	if err := ParseOpf(&original); err != nil {
+		return nil, err
+	}
+	writer := &metadataWriter{
+		document:        document,
+		packageLanguage: model.ParseLanguage(document.Lang),
+		packageDir:      model.ParseDirection(document.Dir),
+	}
+	before, after := original.Metadata, book.Metadata
+	if before.MainId != after.MainId || !equal(before.Identifiers, after.Identifiers) {
+		writer.replace("identifier", writer.identifiers(after))
+	}
+	replaceEntries(writer, "title", model.Values(before.Titles), model.Values(after.Titles), []string{"title-type", "file-as"}, writer.title)
+	replaceEntries(writer, "language", model.Values(before.Languages), model.Values(after.Languages), nil, writer.language)
+	replaceEntries(writer, "creator", model.Values(before.Creators), model.Values(after.Creators), []string{"role", "file-as"}, writer.creator("creator"))
+	replaceEntries(writer, "contributor", model.Values(before.Contributors), model.Values(after.Contributors), []string{"role", "file-as"}, writer.creator("contributor"))
+	replaceEntries(writer, "publisher", model.Values(before.Publishers), model.Values(after.Publishers), nil, writer.defaultAttribute("publisher"))
+	replaceEntries(writer, "subject", model.Values(before.Subjects), model.Values(after.Subjects), nil, writer.defaultAttribute("subject"))
+	replaceEntries(writer, "description", model.Values(before.Descriptions), model.Values(after.Descriptions), nil, writer.defaultAttribute("description"))
+	replaceEntries(writer, "date", model.Values(before.Dates), model.Values(after.Dates), nil, writer.date)
+	document.Replace(opf.NamespaceOpf, func(element opf.Element) bool {
+		return element.Name.Local == "meta" && element.Refines() == "" && element.Attribute("", "property") == "dcterms:modified"
+	}, []string{opf.Markup("meta", modified.UTC().Format(modifiedFormat), "property", "dcterms:modified")})
+	return document.Bytes(), nil
+}
+
+func equal[T any](before *[]T, after *[]T) bool {
+	return reflect.DeepEqual(model.Values(before), model.Values(after)) || len(model.Values(before))+len(model.Values(after)) == 0
+}
+
+type metadataWriter struct {
+	document        *opf.Document
+	packageLanguage model.Language
+	packageDir      model.Direction
+}
+
+func (writer *metadataWriter) replace(local string, markup []string) {
+	writer.document.Replace(opf.NamespaceDC, func(element opf.Element) bool {
+		return element.Is(opf.NamespaceDC, local)
+	}, markup)
+}
+
+// element serializes a dc element, declaring the dc namespace if the
+// document does not.
+func (writer *metadataWriter) element(local string, text string, attrs ...string) string {
+	name, declaration := writer.document.QualifiedName(opf.NamespaceDC, local, "dc")
+	return opf.Markup(name, text, append(declaration, attrs...)...)
+}
+
+// languageAttributes returns the xml:lang and dir attributes that differ
+// from the package element.
+func (writer *metadataWriter) languageAttributes(language model.Language, dir model.Direction) []string {
+	var attrs []string
+	if !language.IsEmpty() && !language.Equal(writer.packageLanguage) {
+		attrs = append(attrs, "xml:lang", rawLanguage(language))
+	}
+	if dir != "" && dir != writer.packageDir {
+		attrs = append(attrs, "dir", string(dir))
+	}
+	return attrs
+}
+
+func rawLanguage(language model.Language) string {
+	if language.Raw != "" {
+		return language.Raw
+	}
+	return language.String()
+}
+
+func refines(id string, property string, text string, attrs ...string) string {
+	return opf.Markup("meta", text, append([]string{"refines", "#" + id, "property", property}, attrs...)...)
+}
+
+func (writer *metadataWriter) identifiers(metadata model.Metadata) []string {
+	identifiers := model.Values(metadata.Identifiers)
+	found := false
+	for _, identifier := range identifiers {
+		found = found || identifier == metadata.MainId
+	}
+	if !found && metadata.MainId.Id != "" {
+		identifiers = append([]model.Identifier{metadata.MainId}, identifiers...)
+	}
+	var markup []string
+	for _, identifier := range identifiers {
+		id := ""
+		if identifier == metadata.MainId {
+			id = writer.document.UniqueIdentifier
+		}
+		text := identifier.Id
+		if identifier.Scheme != "" {
+			text = identifier.Scheme + ":" + identifier.Id
+		}
+		markup = append(markup, writer.element("identifier", text, "id", id))
+	}
+	return markup
+}
+
+func (writer *metadataWriter) title(title model.Title, id string) (string, []string) {
+	var metas []string
+	if title.Type != "" {
+		metas = append(metas, refines(id, "title-type", title.Type))
+	}
+	if title.FileAs != "" && title.FileAsOrigin != model.OriginGenerated {
+		metas = append(metas, refines(id, "file-as", title.FileAs))
+	}
+	attrs := append([]string{"id", id}, writer.languageAttributes(title.Language, title.Dir)...)
+	return writer.element("title", title.Title, attrs...), metas
+}
+
+func (writer *metadataWriter) language(language model.Language, id string) (string, []string) {
+	return writer.element("language", rawLanguage(language), "id", id), nil
+}
+
+func (writer *metadataWriter) creator(local string) func(creator model.Creator, id string) (string, []string) {
+	return func(creator model.Creator, id string) (string, []string) {
+		var metas []string
+		if code, scheme := roleCode(creator); code != "" {
+			metas = append(metas, refines(id, "role", code, "scheme", scheme))
+		}
+		if creator.FileAs != "" && creator.FileAsOrigin != model.OriginGenerated {
+			metas = append(metas, refines(id, "file-as", creator.FileAs))
+		}
+		attrs := append([]string{"id", id}, writer.languageAttributes(creator.Language, creator.Dir)...)
+		return writer.element(local, creator.Name, attrs...), metas
+	}
+}
+
+// roleCode returns the role code of a creator. Creators added without a
+// code are looked up by their English MARC relator label.
+func roleCode(creator model.Creator) (string, string) {
+	scheme := creator.RoleScheme
+	if scheme == "" {
+		scheme = roles.SchemeMarcRelators
+	}
+	if creator.RawRole != "" {
+		return creator.RawRole, scheme
+	}
+	if creator.Role == "" || creator.Role == roles.Unknown {
+		return "", ""
+	}
+	code, _ := model.Relator.Code(creator.Role)
+	return code, roles.SchemeMarcRelators
+}
+
+func (writer *metadataWriter) defaultAttribute(local string) func(attribute model.DefaultAttributes, id string) (string, []string) {
+	return func(attribute model.DefaultAttributes, id string) (string, []string) {
+		attrs := append([]string{"id", id}, writer.languageAttributes(attribute.Language, attribute.Dir)...)
+		return writer.element(local, attribute.Text, attrs...), nil
+	}
+}
+
+func (writer *metadataWriter) date(date string, id string) (string, []string) {
+	return writer.element("date", date, "id", id), nil
+}
+
+// replaceEntries rewrites the dc elements of one kind of metadata entry by
+// entry. Unchanged entries are kept. Changed entries keep their id and the
+// metas refining them, except those of the owned properties, which markup
+// generates again. Entries missing from after are removed with their metas,
+// new entries are inserted after the last one.
+func replaceEntries[T any](writer *metadataWriter, local string, before []T, after []T, owned []string, markup func(entry T, id string) (string, []string)) {
+	document := writer.document
+	var elements []opf.Element
+	for _, element := range document.Metadata {
+		if element.Is(opf.NamespaceDC, local) {
+			elements = append(elements, element)
+		}
+	}
+	for i, element := range elements {
+		id := element.Attribute("", "id")
+		if i >= len(after) {
+			document.Remove(element)
+			for _, meta := range document.Refinements(id) {
+				document.Remove(meta)
+			}
+			continue
+		}
+		if i < len(before) && reflect.DeepEqual(before[i], after[i]) {
+			continue
+		}
+		document.InsertBefore(element, entryMarkup(writer, local, after[i], id, markup))
+		document.Remove(element)
+		for _, meta := range document.Refinements(id) {
+			if slices.Contains(owned, meta.Attribute("", "property")) {
+				document.Remove(meta)
+			}
+		}
+	}
+	var added []string
+	for i := len(elements); i < len(after); i++ {
+		added = append(added, entryMarkup(writer, local, after[i], "", markup)...)
+	}
+	if len(added) == 0 {
+		return
+	}
+	if len(elements) == 0 {
+		document.Add(opf.NamespaceDC, added)
+		return
+	}
+	last := elements[len(elements)-1]
+	for _, meta := range document.Refinements(last.Attribute("", "id")) {
+		if meta.Start > last.Start {
+			last = meta
+		}
+	}
+	document.InsertAfter(last, added)
+}
+
+// entry returns the markup of an entry and its metas. Entries without an id
+// get a new one if they need metas.
+func entryMarkup[T any](writer *metadataWriter, local string, entry T, id string, markup func(entry T, id string) (string, []string)) []string {
+	element, metas := markup(entry, id)
+	if id == "" && len(metas) > 0 {
+		id = writer.document.NewId(local)
+		element, metas = markup(entry, id)
+	}
+	return append([]string{element}, metas...)
+}
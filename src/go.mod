@@ -1,5 +1,12 @@
 module github.com/mathieu-keller/epub-parser
 
-go 1.23
+go 1.23.0
 
 toolchain go1.23.6
+
+require (
+	github.com/yuin/goldmark v1.7.17
+	golang.org/x/net v0.43.0
+	golang.org/x/text v0.28.0
+	gopkg.in/yaml.v3 v3.0.1
+)
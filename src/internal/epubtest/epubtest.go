@@ -0,0 +1,137 @@
+// Package epubtest builds in-memory EPUB archives for the tests of this
+// module.
+package epubtest
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"sort"
+	"testing"
+
+	epub "github.com/mathieu-keller/epub-parser"
+	"github.com/mathieu-keller/epub-parser/model"
+)
+
+// Container points to content.opf in the root of the archive. Open adds it if
+// the files have no META-INF/container.xml.
+const Container = `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>`
+
+// Entry is a file of an archive written in order. Method is the compression
+// method; methods unknown to archive/zip are written uncompressed, so that
+// reading the entry fails.
+type Entry struct {
+	Name    string
+	Content string
+	Method  uint16
+}
+
+// Open returns the book of files, which map paths in the archive to their
+// content.
+func Open(t *testing.T, files map[string]string) *model.Book {
+	if _, ok := files["META-INF/container.xml"]; !ok {
+		withContainer := map[string]string{"META-INF/container.xml": Container}
+		for name, content := range files {
+			withContainer[name] = content
+		}
+		files = withContainer
+	}
+	return OpenReader(t, Zip(t, files))
+}
+
+// OpenFile returns the book of an EPUB file.
+func OpenFile(t *testing.T, filePath string) *model.Book {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return OpenReader(t, Read(t, data))
+}
+
+func OpenReader(t *testing.T, reader *zip.Reader) *model.Book {
+	book, err := epub.OpenBook(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return book
+}
+
+// Reopen writes the book and opens the written archive.
+func Reopen(t *testing.T, book *model.Book) *model.Book {
+	return OpenReader(t, Write(t, book))
+}
+
+// Write writes the book and returns the written archive.
+func Write(t *testing.T, book *model.Book) *zip.Reader {
+	buffer := new(bytes.Buffer)
+	if _, err := book.WriteTo(buffer); err != nil {
+		t.Fatal(err)
+	}
+	return Read(t, buffer.Bytes())
+}
+
+// Zip returns an archive of files in name order. A mimetype file is written
+// first and uncompressed, everything else deflated.
+func Zip(t *testing.T, files map[string]string) *zip.Reader {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		if name != "mimetype" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var entries []Entry
+	if content, ok := files["mimetype"]; ok {
+		entries = append(entries, Entry{Name: "mimetype", Content: content, Method: zip.Store})
+	}
+	for _, name := range names {
+		entries = append(entries, Entry{Name: name, Content: files[name], Method: zip.Deflate})
+	}
+	return ZipEntries(t, entries)
+}
+
+// ZipEntries returns an archive of the entries in their order.
+func ZipEntries(t *testing.T, entries []Entry) *zip.Reader {
+	buffer := new(bytes.Buffer)
+	writer := zip.NewWriter(buffer)
+	for _, entry := range entries {
+		if entry.Method != zip.Store && entry.Method != zip.Deflate {
+			writer.RegisterCompressor(entry.Method, func(out io.Writer) (io.WriteCloser, error) {
+				return nopCloser{out}, nil
+			})
+		}
+		file, err := writer.CreateHeader(&zip.FileHeader{Name: entry.Name, Method: entry.Method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = file.Write([]byte(entry.Content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return Read(t, buffer.Bytes())
+}
+
+func Read(t *testing.T, data []byte) *zip.Reader {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reader
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -25,6 +25,7 @@ func OpenBook(reader *zip.Reader) (*model.Book, error) {
 	if err != nil {
 		return nil, err
 	}
+	book.Version = header.Version
 	switch {
 	case ebookVersion >= 3.0 && ebookVersion < 4.0:
 		err := epub_v3.ParseOpf(book)
@@ -1,52 +1,953 @@
-package epub
+package epub_test
 
 import (
 	"archive/zip"
 	"bytes"
+	epub "github.com/mathieu-keller/epub-parser"
+	"github.com/mathieu-keller/epub-parser/cfi"
+	"github.com/mathieu-keller/epub-parser/epub_v3"
+	"github.com/mathieu-keller/epub-parser/internal/epubtest"
 	"github.com/mathieu-keller/epub-parser/model"
-	"os"
 	"strconv"
+	"strings"
 	"testing"
 )
 
 func Test_parse_epub_2_0_opf(t *testing.T) {
-	binaryFile, err := os.ReadFile("./test_epub_v2.0.epub")
+	book := epubtest.OpenFile(t, "./test_epub_v2.0.epub")
+	assertMetadata(t, book.Metadata)
+	assertEquals("title.FileAsOrigin", t, string((*book.Metadata.Titles)[0].FileAsOrigin), "generated")
+}
+
+func Test_parse_epub_3_0_opf(t *testing.T) {
+	book := epubtest.OpenFile(t, "./test_epub_v3.0.epub")
+	assertMetadata(t, book.Metadata)
+	assertEquals("title.FileAsOrigin", t, string((*book.Metadata.Titles)[0].FileAsOrigin), "book")
+}
+
+func Test_language_inherited_from_package(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"content.opf": `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id" xml:lang="DE_de">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title>Der Steppenwolf</dc:title>
+    <dc:title xml:lang="en">Steppenwolf</dc:title>
+    <dc:creator>Hermann Hesse</dc:creator>
+    <dc:language>ger</dc:language>
+  </metadata>
+</package>`,
+	})
+	titles := *book.Metadata.Titles
+	assertEquals("titles[0].Language", t, titles[0].Language.String(), "de-DE")
+	assertEquals("titles[0].FileAs", t, titles[0].FileAs, "Steppenwolf, Der")
+	assertEquals("titles[1].Language", t, titles[1].Language.String(), "en")
+	assertEquals("creators.Language", t, (*book.Metadata.Creators)[0].Language.String(), "de-DE")
+	language := (*book.Metadata.Languages)[0]
+	assertEquals("language", t, language.String(), "de")
+	assertEquals("language.DisplayName", t, language.DisplayName("en"), "German")
+	assertEquals("language.DisplayName self", t, language.DisplayName(""), "Deutsch")
+}
+
+func Test_identifier_without_scheme(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"content.opf": `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">12345</dc:identifier>
+    <dc:identifier>urn:uuid:a:b</dc:identifier>
+    <dc:title>Title</dc:title>
+    <dc:language>en</dc:language>
+  </metadata>
+</package>`,
+	})
+	assertEquals("mainId.Id", t, book.Metadata.MainId.Id, "12345")
+	assertEquals("mainId.Scheme", t, book.Metadata.MainId.Scheme, "")
+	identifier := (*book.Metadata.Identifiers)[1]
+	assertEquals("identifiers[1].Scheme", t, identifier.Scheme, "urn")
+	assertEquals("identifiers[1].Id", t, identifier.Id, "uuid:a:b")
+}
+
+func Test_accessibility_metadata(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"content.opf": `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title>Accessible</dc:title>
+    <dc:language>en</dc:language>
+    <meta property="schema:accessMode">textual</meta>
+    <meta property="schema:accessMode">visual</meta>
+    <meta property="schema:accessModeSufficient">textual,visual</meta>
+    <meta property="schema:accessModeSufficient">textual</meta>
+    <meta property="schema:accessibilityFeature">structuralNavigation</meta>
+    <meta property="schema:accessibilityFeature">alttext</meta>
+    <meta property="schema:accessibilityHazard">none</meta>
+    <meta property="schema:accessibilitySummary">This publication conforms to WCAG 2.1 Level AA.</meta>
+    <meta property="dcterms:conformsTo">EPUB Accessibility 1.1 - WCAG 2.1 Level AA</meta>
+    <meta property="a11y:certifiedBy" id="certifier">Accessibility Testers Inc.</meta>
+    <meta property="a11y:certifierCredential" refines="#certifier">DAISY Ace</meta>
+    <link rel="a11y:certifierReport" href="https://example.com/report"/>
+  </metadata>
+</package>`,
+	})
+	accessibility := book.Metadata.Accessibility
+	if accessibility == nil {
+		t.Fatal("accessibility expected")
+	}
+	assertSize("accessModes size", t, len(accessibility.AccessModes), 2)
+	assertSize("accessModesSufficient size", t, len(accessibility.AccessModesSufficient), 2)
+	assertSize("accessModesSufficient[0] size", t, len(accessibility.AccessModesSufficient[0]), 2)
+	assertSize("features size", t, len(accessibility.Features), 1)
+	assertEquals("features[0]", t, accessibility.Features[0], "structuralNavigation")
+	assertSize("invalidValues size", t, len(accessibility.InvalidValues), 1)
+	assertEquals("invalidValues[0].Value", t, accessibility.InvalidValues[0].Value, "alttext")
+	assertEquals("hazards[0]", t, accessibility.Hazards[0], "none")
+	assertEquals("summary", t, accessibility.Summary, "This publication conforms to WCAG 2.1 Level AA.")
+	assertEquals("conformsTo[0]", t, accessibility.ConformsTo[0], "EPUB Accessibility 1.1 - WCAG 2.1 Level AA")
+	assertEquals("certifiedBy", t, accessibility.CertifiedBy, "Accessibility Testers Inc.")
+	assertEquals("certifierCredential", t, accessibility.CertifierCredential, "DAISY Ace")
+	assertEquals("certifierReport", t, accessibility.CertifierReport, "https://example.com/report")
+}
+
+func Test_fixed_layout_rendition(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"content.opf": `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title>Comic</dc:title>
+    <dc:language>en</dc:language>
+    <meta property="rendition:layout">pre-paginated</meta>
+    <meta property="rendition:spread">landscape</meta>
+    <meta property="rendition:orientation">sideways</meta>
+  </metadata>
+  <manifest>
+    <item id="p1" href="p1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="p2" href="p2.xhtml" media-type="application/xhtml+xml"/>
+    <item id="p3" href="p3.xhtml" media-type="application/xhtml+xml"/>
+    <item id="p4" href="p4.xhtml" media-type="application/xhtml+xml"/>
+    <item id="p5" href="p5.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="p1" properties="page-spread-right"/>
+    <itemref idref="p2" properties="page-spread-left rendition:spread-none"/>
+    <itemref idref="p3" properties="rendition:layout-reflowable rendition:page-spread-center" linear="no"/>
+    <itemref idref="p4" properties="rendition:page-spread-left"/>
+    <itemref idref="p5" properties="rendition:page-spread-right"/>
+  </spine>
+</package>`,
+	})
+	assertEquals("rendition.Layout", t, book.Rendition.Layout, "pre-paginated")
+	assertEquals("rendition.Spread", t, book.Rendition.Spread, "landscape")
+	assertEquals("rendition.Orientation", t, book.Rendition.Orientation, "auto")
+	assertEquals("rendition.Flow", t, book.Rendition.Flow, "auto")
+	spine := *book.Spine
+	assertSize("spine size", t, len(spine), 5)
+	assertEquals("spine[0].PageSpread", t, spine[0].Rendition.PageSpread, "right")
+	assertEquals("spine[0].Layout", t, spine[0].Rendition.Layout, "pre-paginated")
+	assertEquals("spine[1].PageSpread", t, spine[1].Rendition.PageSpread, "left")
+	assertEquals("spine[1].Spread", t, spine[1].Rendition.Spread, "none")
+	assertEquals("spine[2].Layout", t, spine[2].Rendition.Layout, "reflowable")
+	assertEquals("spine[2].PageSpread", t, spine[2].Rendition.PageSpread, "center")
+	assertEquals("spine[2].Linear", t, strconv.FormatBool(spine[2].Linear), "false")
+	assertEquals("spine[3].PageSpread", t, spine[3].Rendition.PageSpread, "left")
+	assertEquals("spine[4].PageSpread", t, spine[4].Rendition.PageSpread, "right")
+}
+
+func Test_display_options_fallback(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"content.opf": `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">1</dc:identifier>
+    <dc:title>Picture book</dc:title>
+    <dc:language>en</dc:language>
+  </metadata>
+  <manifest>
+    <item id="p1" href="p1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="p1"/>
+  </spine>
+</package>`,
+		"META-INF/com.apple.ibooks.display-options.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<display_options>
+  <platform name="*">
+    <option name="fixed-layout">true</option>
+    <option name="orientation-lock">landscape-only</option>
+  </platform>
+</display_options>`,
+	})
+	assertEquals("rendition.Layout", t, book.Rendition.Layout, "pre-paginated")
+	assertEquals("rendition.Orientation", t, book.Rendition.Orientation, "landscape")
+	assertEquals("spine[0].Layout", t, (*book.Spine)[0].Rendition.Layout, "pre-paginated")
+}
+
+func Test_broken_display_options_ignored(t *testing.T) {
+	for _, version := range []string{"2.0", "3.0"} {
+		book := epubtest.Open(t, map[string]string{
+			"content.opf": `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="` + version + `" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">1</dc:identifier>
+    <dc:title>Broken options</dc:title>
+    <dc:language>en</dc:language>
+  </metadata>
+  <manifest>
+    <item id="p1" href="p1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="p1"/>
+  </spine>
+</package>`,
+			"META-INF/com.apple.ibooks.display-options.xml": `<display_options><platform name="*"><option name="fixed-layout">true</option>`,
+		})
+		assertEquals("rendition.Layout "+version, t, book.Rendition.Layout, "reflowable")
+	}
+}
+
+func Test_right_to_left(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"content.opf": `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id" dir="rtl" xml:lang="ar">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title>كتاب</dc:title>
+    <dc:title dir="ltr" xml:lang="en">Book</dc:title>
+    <dc:creator>مؤلف</dc:creator>
+    <dc:description>وصف</dc:description>
+    <dc:language>ar</dc:language>
+  </metadata>
+  <manifest>
+    <item id="c1" href="c1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine page-progression-direction="rtl">
+    <itemref idref="c1"/>
+  </spine>
+</package>`,
+	})
+	titles := *book.Metadata.Titles
+	assertEquals("titles[0].Dir", t, string(titles[0].Dir), "rtl")
+	assertEquals("titles[1].Dir", t, string(titles[1].Dir), "ltr")
+	assertEquals("creators[0].Dir", t, string((*book.Metadata.Creators)[0].Dir), "rtl")
+	assertEquals("descriptions[0].Dir", t, string((*book.Metadata.Descriptions)[0].Dir), "rtl")
+	assertEquals("PageProgressionDirection", t, string(book.PageProgressionDirection), "rtl")
+	assertEquals("ReadingDirection", t, string(book.ReadingDirection()), "rtl")
+}
+
+func Test_reading_direction_from_language(t *testing.T) {
+	book := &model.Book{
+		PageProgressionDirection: model.DirectionDefault,
+		Metadata:                 model.Metadata{Languages: &[]model.Language{model.ParseLanguage("he")}},
+	}
+	assertEquals("ReadingDirection", t, string(book.ReadingDirection()), "rtl")
+	book.Metadata.Languages = &[]model.Language{model.ParseLanguage("ja")}
+	assertEquals("ReadingDirection", t, string(book.ReadingDirection()), "ltr")
+}
+
+func Test_media_overlays(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"content.opf": `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title>Read aloud</dc:title>
+    <dc:language>en</dc:language>
+    <meta property="media:duration">0:01:10</meta>
+    <meta property="media:duration" refines="#c1-overlay">0:01:10.5</meta>
+    <meta property="media:narrator">Jane Doe</meta>
+    <meta property="media:active-class">-epub-media-overlay-active</meta>
+    <meta property="media:playback-active-class">-epub-media-overlay-playing</meta>
+  </metadata>
+  <manifest>
+    <item id="c1" href="text/c1.xhtml" media-type="application/xhtml+xml" media-overlay="c1-overlay"/>
+    <item id="c1-overlay" href="smil/c1.smil" media-type="application/smil+xml"/>
+    <item id="c1-audio" href="audio/c1.mp3" media-type="audio/mpeg"/>
+  </manifest>
+  <spine>
+    <itemref idref="c1"/>
+  </spine>
+</package>`,
+		"smil/c1.smil": `<?xml version="1.0" encoding="UTF-8"?>
+<smil xmlns="http://www.w3.org/ns/SMIL" xmlns:epub="http://www.idpf.org/2007/ops" version="3.0">
+  <body>
+    <par id="p1">
+      <text src="../text/c1.xhtml#heading"/>
+      <audio src="../audio/c1.mp3" clipBegin="0:00:00" clipEnd="0:00:02.5"/>
+    </par>
+    <seq epub:textref="../text/c1.xhtml#figure" epub:type="figure">
+      <par id="p2" epub:type="caption">
+        <text src="../text/c1.xhtml#caption"/>
+        <audio src="../audio/c1.mp3" clipBegin="2.5s" clipEnd="5s"/>
+      </par>
+    </seq>
+    <par id="p3">
+      <text src="../text/c1.xhtml#end"/>
+    </par>
+  </body>
+</smil>`,
+	})
+	assertEquals("Duration", t, book.MediaOverlays.Duration.String(), "1m10s")
+	assertEquals("Narrators[0]", t, book.MediaOverlays.Narrators[0], "Jane Doe")
+	assertEquals("ActiveClass", t, book.MediaOverlays.ActiveClass, "-epub-media-overlay-active")
+	assertEquals("PlaybackActiveClass", t, book.MediaOverlays.PlaybackActiveClass, "-epub-media-overlay-playing")
+
+	chapter, _ := book.ManifestItem("c1")
+	overlayItem, ok := book.MediaOverlayFor(chapter)
+	if !ok {
+		t.Fatal("media overlay expected")
+	}
+	overlay, err := book.ReadMediaOverlay(overlayItem)
 	if err != nil {
-		t.Log(err.Error())
+		t.Fatal(err)
+	}
+	assertEquals("overlay.Duration", t, overlay.Duration.String(), "1m10.5s")
+	assertSize("pars size", t, len(overlay.Pars), 3)
+	assertEquals("pars[0].TextHref", t, overlay.Pars[0].TextHref, "text/c1.xhtml")
+	assertEquals("pars[0].TextFragment", t, overlay.Pars[0].TextFragment, "heading")
+	assertEquals("pars[0].AudioHref", t, overlay.Pars[0].AudioHref, "audio/c1.mp3")
+	assertEquals("pars[0].ClipEnd", t, overlay.Pars[0].ClipEnd.String(), "2.5s")
+	assertEquals("pars[1].Id", t, overlay.Pars[1].Id, "p2")
+	assertEquals("pars[1].Type", t, overlay.Pars[1].Type, "caption")
+	assertEquals("pars[1].ClipBegin", t, overlay.Pars[1].ClipBegin.String(), "2.5s")
+	assertEquals("pars[2].AudioHref", t, overlay.Pars[2].AudioHref, "")
+}
+
+func Test_guide_landmarks(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"content.opf": `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title>Guide</dc:title>
+  </metadata>
+  <manifest>
+    <item id="cover" href="cover.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c1" href="text/chapter%201.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="cover"/>
+    <itemref idref="c1"/>
+  </spine>
+  <guide>
+    <reference type="cover" title="Cover" href="cover.xhtml"/>
+    <reference type="text" title="Start" href="text/chapter%201.xhtml#start"/>
+    <reference type="other.ms-coverimage" href="cover.jpg"/>
+  </guide>
+</package>`,
+	})
+	assertSize("Landmarks", t, len(book.Landmarks), 3)
+	assertEquals("Landmarks[0].Type", t, book.Landmarks[0].Type, "cover")
+	assertEquals("Landmarks[0].Source", t, string(book.Landmarks[0].Source), "guide")
+	start, ok := book.Landmark("bodymatter")
+	if !ok {
+		t.Fatal("bodymatter landmark expected")
+	}
+	assertEquals("start.RawType", t, start.RawType, "text")
+	assertEquals("start.Title", t, start.Title, "Start")
+	assertEquals("start.Href", t, start.Href, "text/chapter 1.xhtml")
+	assertEquals("start.Fragment", t, start.Fragment, "start")
+	assertEquals("Landmarks[2].Type", t, book.Landmarks[2].Type, "ms-coverimage")
+}
+
+func Test_nav_landmarks_merged_with_guide(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"content.opf": `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title>Landmarks</dc:title>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav/nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="c1" href="text/c1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="c1"/>
+  </spine>
+  <guide>
+    <reference type="text" title="Begin" href="text/c1.xhtml"/>
+    <reference type="copyright-page" title="Copyright" href="text/c1.xhtml#copyright"/>
+  </guide>
+</package>`,
+		"nav/nav.xhtml": `<?xml version="1.0" encoding="utf-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<body>
+  <nav epub:type="toc"><ol><li><a href="../text/c1.xhtml">Chapter 1</a></li></ol></nav>
+  <nav epub:type="landmarks" hidden="">
+    <ol>
+      <li><a epub:type="toc" href="nav.xhtml#toc">Table of
+        Contents</a></li>
+      <li><a epub:type="bodymatter" href="../text/c1.xhtml#start">Start of Content</a></li>
+    </ol>
+  </nav>
+</body>
+</html>`,
+	})
+	assertSize("Landmarks", t, len(book.Landmarks), 3)
+	assertEquals("Landmarks[0].Title", t, book.Landmarks[0].Title, "Table of Contents")
+	assertEquals("Landmarks[0].Href", t, book.Landmarks[0].Href, "nav/nav.xhtml")
+	assertEquals("Landmarks[1].Type", t, book.Landmarks[1].Type, "bodymatter")
+	assertEquals("Landmarks[1].Href", t, book.Landmarks[1].Href, "text/c1.xhtml")
+	assertEquals("Landmarks[1].Source", t, string(book.Landmarks[1].Source), "nav")
+	assertEquals("Landmarks[2].Type", t, book.Landmarks[2].Type, "copyright-page")
+	assertEquals("Landmarks[2].Source", t, string(book.Landmarks[2].Source), "guide")
+}
+
+func Test_unreadable_nav_keeps_guide_landmarks(t *testing.T) {
+	reader := epubtest.ZipEntries(t, []epubtest.Entry{
+		{Name: "META-INF/container.xml", Content: epubtest.Container},
+		{Name: "content.opf", Content: `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title>Landmarks</dc:title>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="c1" href="c1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="c1"/>
+  </spine>
+  <guide>
+    <reference type="text" title="Begin" href="c1.xhtml"/>
+  </guide>
+</package>`},
+		// An unknown compression method makes the nav document unreadable.
+		{Name: "nav.xhtml", Content: "<html/>", Method: 99},
+	})
+	book := epubtest.OpenReader(t, reader)
+	assertSize("Landmarks", t, len(book.Landmarks), 1)
+	assertEquals("Landmarks[0].Type", t, book.Landmarks[0].Type, "bodymatter")
+}
+
+func Test_extract_text(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"content.opf": `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title>Text</dc:title>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="c1" href="text/c1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c2" href="text/c2.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c3" href="text/c3.dtbook" media-type="application/x-dtbook+xml" fallback="c3-html"/>
+    <item id="c3-html" href="text/c3.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c4" href="text/missing.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="c1"/>
+    <itemref idref="c2"/>
+    <itemref idref="c3"/>
+    <itemref idref="c4"/>
+  </spine>
+</package>`,
+		"nav.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops"><body>
+<nav epub:type="toc"><ol>
+  <li><a href="text/c1.xhtml">Chapter One</a>
+    <ol><li><a href="text/c2.xhtml#part">Part Two</a></li></ol>
+  </li>
+</ol></nav></body></html>`,
+		"text/c1.xhtml": `<?xml version="1.0" encoding="utf-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head><title>Ignored</title><style>p { color: red; }</style></head>
+<body>
+  <h1>Chapter   One</h1>
+  <p>Caf&eacute; &amp; <em>cr&#232;me</em>
+     br&ucirc;l&eacute;e.<br/>Second line</p>
+  <script>var ignored = true;</script>
+  <p>Unclosed paragraph
+  <p>Next <b>paragraph</b><img src="a.png" alt=""/></p>
+  <ul><li>One</li><li>Two</li></ul>
+</body>
+</html>`,
+		"text/c2.xhtml": `<html><body><pre>  keep
+    spacing</pre><table><tr><td>a</td><td>b</td></tr></table></body></html>`,
+		"text/c3.dtbook": `<dtbook/>`,
+		"text/c3.xhtml":  `<html><body><p>Fallback</p></body></html>`,
+	})
+	chapters, err := book.ExtractText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSize("chapters", t, len(chapters), 4)
+	assertEquals("chapters[0].Title", t, chapters[0].Title, "Chapter One")
+	assertEquals("chapters[0].Href", t, chapters[0].Href, "text/c1.xhtml")
+	assertEquals("chapters[0].Text", t, chapters[0].Text,
+		"Chapter One\n\nCafé & crème brûlée.\nSecond line\n\nUnclosed paragraph\n\nNext paragraph\n\nOne\nTwo")
+	assertEquals("chapters[1].Title", t, chapters[1].Title, "Part Two")
+	assertEquals("chapters[1].Text", t, chapters[1].Text, "  keep\n    spacing\n\na b")
+	assertEquals("chapters[2].SpineIndex", t, strconv.Itoa(chapters[2].SpineIndex), "2")
+	assertEquals("chapters[2].Item.Id", t, chapters[2].Item.Id, "c3-html")
+	assertEquals("chapters[2].Text", t, chapters[2].Text, "Fallback")
+	if chapters[3].Err == nil || chapters[3].Href != "text/missing.xhtml" || chapters[3].Text != "" {
+		t.Logf("expected an error for the missing chapter but got %v", chapters[3])
 		t.Fail()
 	}
-	zipReader, err := zip.NewReader(bytes.NewReader(binaryFile), int64(len(binaryFile)))
+
+	statistics, err := book.Statistics()
 	if err != nil {
-		t.Log(err.Error())
+		t.Fatal(err)
+	}
+	assertSize("statistics.Chapters", t, len(statistics.Chapters), 4)
+	if statistics.Chapters[3].Err == nil {
+		t.Log("expected an error for the missing chapter")
 		t.Fail()
 	}
+	assertEquals("statistics.Chapters[0].Title", t, statistics.Chapters[0].Title, "Chapter One")
+	assertSize("statistics.Chapters[0].Words", t, statistics.Chapters[0].Words, 13)
+	assertSize("statistics.Chapters[0].Paragraphs", t, statistics.Chapters[0].Paragraphs, 5)
+	assertSize("statistics.Chapters[0].Images", t, statistics.Chapters[0].Images, 1)
+	assertSize("statistics.Words", t, statistics.Words, 18)
+	assertSize("statistics.Paragraphs", t, statistics.Paragraphs, 8)
+	assertEquals("statistics.ReadingTime", t, statistics.ReadingTime(9).String(), "2m0s")
+	results, err := book.Search("fallback", model.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSize("results", t, len(results), 1)
+}
 
-	book, err := OpenBook(zipReader)
+func Test_toc_from_ncx(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"content.opf": `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title>NCX</dc:title>
+  </metadata>
+  <manifest>
+    <item id="ncx" href="toc/toc.ncx" media-type="application/x-dtbncx+xml"/>
+    <item id="c1" href="c1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine toc="ncx">
+    <itemref idref="c1"/>
+  </spine>
+</package>`,
+		"toc/toc.ncx": `<?xml version="1.0" encoding="utf-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <navMap>
+    <navPoint id="n1" playOrder="1">
+      <navLabel><text>First
+        Chapter</text></navLabel>
+      <content src="../c1.xhtml"/>
+      <navPoint id="n2" playOrder="2">
+        <navLabel><text>Section</text></navLabel>
+        <content src="../c1.xhtml#s1"/>
+      </navPoint>
+    </navPoint>
+  </navMap>
+</ncx>`,
+	})
+	toc, err := book.ReadTOC()
 	if err != nil {
-		t.Log(err.Error())
+		t.Fatal(err)
+	}
+	assertSize("toc", t, len(toc), 1)
+	assertEquals("toc[0].Title", t, toc[0].Title, "First Chapter")
+	assertEquals("toc[0].Href", t, toc[0].Href, "c1.xhtml")
+	assertSize("toc[0].Children", t, len(toc[0].Children), 1)
+	assertEquals("toc[0].Children[0].Fragment", t, toc[0].Children[0].Fragment, "s1")
+}
+
+func Test_search(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"content.opf": `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title>Search</dc:title>
+  </metadata>
+  <manifest>
+    <item id="c1" href="c1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c2" href="c2.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="c1"/>
+    <itemref id="second" idref="c2"/>
+  </spine>
+</package>`,
+		"c1.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"><body><p>Nothing here.</p></body></html>`,
+		"c2.xhtml": `<?xml version="1.0" encoding="utf-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head><title>Cafe</title></head>
+<body>
+  <section id="s1">
+    <p>The <em>Café</em> was closed.</p>
+    <p>We went to another cafe, then to the cafeteria. 😀 CAFE</p>
+  </section>
+</body>
+</html>`,
+	})
+	tests := []struct {
+		query    string
+		options  model.SearchOptions
+		expected []string
+	}{
+		{"cafe", model.SearchOptions{}, []string{"cafe", "cafe", "CAFE"}},
+		{"cafe", model.SearchOptions{CaseSensitive: true}, []string{"cafe", "cafe"}},
+		{"cafe", model.SearchOptions{IgnoreDiacritics: true}, []string{"Café", "cafe", "cafe", "CAFE"}},
+		{"cafe", model.SearchOptions{IgnoreDiacritics: true, WholeWord: true}, []string{"Café", "cafe", "CAFE"}},
+		{"closed.  we", model.SearchOptions{}, []string{"closed.\n\nWe"}},
+	}
+	for _, test := range tests {
+		results, err := book.Search(test.query, test.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var matches []string
+		for _, result := range results {
+			matches = append(matches, result.Match)
+		}
+		if strings.Join(matches, "|") != strings.Join(test.expected, "|") {
+			t.Logf("Search(%q, %+v) expected %q but is %q", test.query, test.options, test.expected, matches)
+			t.Fail()
+		}
+	}
+
+	results, _ := book.Search("café", model.SearchOptions{SnippetLength: 10})
+	assertSize("results", t, len(results), 1)
+	assertEquals("results[0].Href", t, results[0].Href, "c2.xhtml")
+	assertEquals("results[0].SpineIndex", t, strconv.Itoa(results[0].SpineIndex), "1")
+	assertEquals("results[0].Snippet", t, results[0].Snippet, "The Café was…")
+	assertEquals("results[0].CFI", t, results[0].CFI, "epubcfi(/6/4[second]!/4/2[s1]/2/2/1:0)")
+
+	results, _ = book.Search("CAFE", model.SearchOptions{CaseSensitive: true, SnippetLength: 14})
+	assertSize("results", t, len(results), 1)
+	assertEquals("results[0].Snippet", t, results[0].Snippet, "…cafeteria. 😀 CAFE")
+	assertEquals("results[0].CFI", t, results[0].CFI, "epubcfi(/6/4[second]!/4/2[s1]/4/1:51)")
+
+	location, err := book.ResolveCFI(cfi.MustParse(results[0].CFI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals("location.Item.Id", t, location.Item.Id, "c2")
+	assertEquals("location", t, location.Node.Data[location.Offset:], "CAFE")
+	start, end, err := book.ResolveCFIRange(cfi.MustParse("epubcfi(/6/4[second]!/4/2[s1]/2,/1:1,/2/1:2)"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals("start", t, start.Node.Data[start.Offset:], "he ")
+	assertEquals("end", t, end.Node.Data[end.Offset:], "fé")
+	if _, err := book.ResolveCFI(cfi.MustParse("epubcfi(/6/8!/4/2)")); err == nil {
+		t.Log("expected an error for a missing spine item")
+		t.Fail()
+	}
+	if _, err := book.ResolveCFI(cfi.MustParse("epubcfi(/4/2!/4/2)")); err == nil {
+		t.Log("expected an error for a step that is not the spine")
+		t.Fail()
+	}
+	if _, err := book.ResolveCFI(cfi.CFI{Path: cfi.Path{Steps: []cfi.Step{{Index: 6}, {Index: 0, Id: "x"}, {Index: 4, Indirection: true}}}}); err == nil {
+		t.Log("expected an error for spine step 0")
 		t.Fail()
 	}
-	assertMetadata(t, book.Metadata)
 }
 
-func Test_parse_epub_3_0_opf(t *testing.T) {
-	binaryFile, err := os.ReadFile("./test_epub_v3.0.epub")
+func Test_cfi_spine_step(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"content.opf": `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title>CFI</dc:title>
+  </metadata>
+  <spine>
+    <itemref idref="c1"/>
+  </spine>
+  <manifest>
+    <item id="c1" href="c1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+</package>`,
+		"c1.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"><head><title>One</title></head><body><p id="p1">One</p></body></html>`,
+	})
+	element, err := book.ElementCFI(0, "p1")
 	if err != nil {
-		t.Log(err.Error())
+		t.Fatal(err)
+	}
+	assertEquals("ElementCFI", t, element.String(), "epubcfi(/4/2!/4/2[p1])")
+	if _, err := book.ResolveCFI(element); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := book.ResolveCFI(cfi.MustParse("epubcfi(/6/2!/4/2)")); err == nil {
+		t.Log("expected an error for a step that is not the spine")
 		t.Fail()
 	}
-	zipReader, err := zip.NewReader(bytes.NewReader(binaryFile), int64(len(binaryFile)))
+}
+
+func Test_generate_cfi(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"content.opf": `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title>CFI</dc:title>
+  </metadata>
+  <manifest>
+    <item id="c1" href="c1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c2" href="c2.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="c1"/>
+    <itemref id="ref2" idref="c2"/>
+  </spine>
+</package>`,
+		"c1.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"><head><title>Empty</title></head><body></body></html>`,
+		"c2.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"><head><title>Two</title></head><body>
+<h1 id="top">Title</h1>
+<p>One two three four five six seven eight nine ten.</p>
+</body></html>`,
+	})
+	spineIndex, ok := book.SpineIndex("c2")
+	if !ok {
+		t.Fatal("spine item c2 expected")
+	}
+	element, err := book.ElementCFI(spineIndex, "top")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals("ElementCFI", t, element.String(), "epubcfi(/6/4[ref2]!/4/2[top])")
+	location, err := book.ResolveCFI(element)
 	if err != nil {
-		t.Log(err.Error())
+		t.Fatal(err)
+	}
+	assertEquals("location.Node.Data", t, location.Node.Data, "h1")
+	if _, err := book.ElementCFI(spineIndex, "missing"); err == nil {
+		t.Log("expected an error for a missing id")
 		t.Fail()
 	}
 
-	book, err := OpenBook(zipReader)
+	chapter, err := book.ExtractSpineText(spineIndex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := book.TextCFI(chapter, strings.Index(chapter.Text, "two"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals("TextCFI", t, text.String(), "epubcfi(/6/4[ref2]!/4/4/1:4)")
+
+	progress, err := book.ProgressCFI(spineIndex, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals("ProgressCFI", t, progress.String(), "epubcfi(/6/4[ref2]!/4/4/1:19)")
+	location, err = book.ResolveCFI(progress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals("location", t, location.Node.Data[location.Offset:], "five six seven eight nine ten.")
+
+	empty, err := book.ProgressCFI(0, 0.3)
 	if err != nil {
-		t.Log(err.Error())
+		t.Fatal(err)
+	}
+	assertEquals("empty", t, empty.String(), "epubcfi(/6/2!/4)")
+	if _, err := book.ProgressCFI(spineIndex, 1.5); err == nil {
+		t.Log("expected an error for progress > 1")
 		t.Fail()
 	}
-	assertMetadata(t, book.Metadata)
+}
+
+func Test_save_metadata_epub_3(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"content.opf": `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id" xml:lang="en">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title id="t1">Old Title</dc:title>
+    <meta refines="#t1" property="title-type">main</meta>
+    <meta refines="#t1" property="alternate-script" xml:lang="de">Alter Titel</meta>
+    <dc:creator id="c1">Jane Doe</dc:creator>
+    <meta refines="#c1" property="role" scheme="marc:relators">aut</meta>
+    <meta refines="#c1" property="display-seq">1</meta>
+    <x:custom xmlns:x="urn:x">kept</x:custom>
+    <dc:language>en</dc:language>
+    <meta property="dcterms:modified">2020-01-01T00:00:00Z</meta>
+  </metadata>
+  <manifest/>
+  <spine/>
+</package>`,
+	})
+	(*book.Metadata.Titles)[0].Title = "New Title"
+	*book.Metadata.Creators = append(*book.Metadata.Creators, model.Creator{Name: "Max Mustermann", FileAs: "Mustermann, Max", FileAsOrigin: model.OriginBook, Role: "illustrator"})
+	err := epub.SaveMetadata(book)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := book.ReadFile("content.opf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opf := string(data)
+	for _, expected := range []string{
+		`    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title id="t1">New Title</dc:title>
+    <meta refines="#t1" property="title-type">main</meta>
+    <meta refines="#t1" property="alternate-script" xml:lang="de">Alter Titel</meta>
+    <dc:creator id="c1">Jane Doe</dc:creator>
+    <meta refines="#c1" property="role" scheme="marc:relators">aut</meta>
+    <meta refines="#c1" property="display-seq">1</meta>
+    <dc:creator id="creator1">Max Mustermann</dc:creator>
+    <meta refines="#creator1" property="role" scheme="marc:relators">ill</meta>
+    <meta refines="#creator1" property="file-as">Mustermann, Max</meta>
+    <x:custom xmlns:x="urn:x">kept</x:custom>
+    <dc:language>en</dc:language>
+    <meta property="dcterms:modified">`,
+	} {
+		if !strings.Contains(opf, expected) {
+			t.Logf("expected package document to contain\n%s\ngot\n%s", expected, opf)
+			t.Fail()
+		}
+	}
+	assertEquals("modified", t, strings.Join(book.ModifiedFiles(), ","), "content.opf")
+	saved := &model.Book{ZipReader: book.ZipReader, Container: book.Container, Version: book.Version}
+	saved.WriteFile("content.opf", data)
+	err = epub_v3.ParseOpf(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals("title", t, (*saved.Metadata.Titles)[0].Title, "New Title")
+	assertSize("creators", t, len(*saved.Metadata.Creators), 2)
+	assertEquals("creators[1].Role", t, (*saved.Metadata.Creators)[1].Role, "illustrator")
+	assertEquals("creators[1].FileAs", t, (*saved.Metadata.Creators)[1].FileAs, "Mustermann, Max")
+}
+
+func Test_save_metadata_epub_3_removed_entry(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"content.opf": `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id" xml:lang="en">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title>Title</dc:title>
+    <dc:creator id="c1">Jane Doe</dc:creator>
+    <meta refines="#c1" property="role" scheme="marc:relators">aut</meta>
+    <dc:creator id="c2">Max Mustermann</dc:creator>
+    <meta refines="#c2" property="role" scheme="marc:relators">ill</meta>
+    <meta refines="#c2" property="alternate-script" xml:lang="de">Max</meta>
+    <dc:language>en</dc:language>
+  </metadata>
+  <manifest/>
+  <spine/>
+</package>`,
+	})
+	*book.Metadata.Creators = (*book.Metadata.Creators)[:1]
+	if err := epub.SaveMetadata(book); err != nil {
+		t.Fatal(err)
+	}
+	data, err := book.ReadFile("content.opf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `    <dc:creator id="c1">Jane Doe</dc:creator>
+    <meta refines="#c1" property="role" scheme="marc:relators">aut</meta>`
+	if opf := string(data); !strings.Contains(opf, expected) || strings.Contains(opf, "c2") || strings.Contains(opf, "Max") {
+		t.Logf("expected package document to contain\n%s\ngot\n%s", expected, opf)
+		t.Fail()
+	}
+}
+
+func Test_save_metadata_epub_2(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"content.opf": `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">
+    <dc:title>Title</dc:title>
+    <dc:creator opf:role="aut">Jane Doe</dc:creator>
+    <dc:identifier id="id" opf:scheme="ISBN">9780000000000</dc:identifier>
+    <dc:date opf:event="publication">2001</dc:date>
+    <dc:date opf:event="modification">2002</dc:date>
+    <meta name="cover" content="cover"/>
+  </metadata>
+  <manifest/>
+  <spine/>
+</package>`,
+	})
+	(*book.Metadata.Creators)[0].FileAs = "Doe, Jane"
+	(*book.Metadata.Creators)[0].FileAsOrigin = model.OriginBook
+	(*book.Metadata.Dates)[1] = "2003"
+	err := epub.SaveMetadata(book)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := book.ReadFile("content.opf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">
+    <dc:title>Title</dc:title>
+    <dc:creator opf:role="aut" opf:file-as="Doe, Jane">Jane Doe</dc:creator>
+    <dc:identifier id="id" opf:scheme="ISBN">9780000000000</dc:identifier>
+    <dc:date opf:event="publication">2001</dc:date>
+    <dc:date opf:event="modification">2003</dc:date>
+    <meta name="cover" content="cover"/>
+  </metadata>`
+	if !strings.Contains(string(data), expected) {
+		t.Logf("expected package document to contain\n%s\ngot\n%s", expected, data)
+		t.Fail()
+	}
+}
+
+func Test_write_to(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"content.opf": `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title>Write</dc:title>
+  </metadata>
+  <manifest>
+    <item id="c1" href="c1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="c1"/>
+  </spine>
+</package>`,
+		"c1.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"><body><p>Old</p></body></html>`,
+	})
+	book.WriteFile("c1.xhtml", []byte(`<html xmlns="http://www.w3.org/1999/xhtml"><body><p>New</p></body></html>`))
+	book.WriteFile("style.css", []byte(`p { margin: 0 }`))
+	buffer := new(bytes.Buffer)
+	n, err := book.WriteTo(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals("written", t, strconv.FormatInt(n, 10), strconv.Itoa(buffer.Len()))
+	reader := epubtest.Read(t, buffer.Bytes())
+	assertEquals("File[0].Name", t, reader.File[0].Name, "mimetype")
+	assertEquals("File[0].Method", t, strconv.Itoa(int(reader.File[0].Method)), strconv.Itoa(int(zip.Store)))
+	assertSize("File[0].Extra", t, len(reader.File[0].Extra), 0)
+	assertEquals("File[1].Name", t, reader.File[1].Name, "META-INF/container.xml")
+	assertSize("files", t, len(reader.File), 5)
+	saved := epubtest.OpenReader(t, reader)
+	mimetype, err := saved.ReadFile("mimetype")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals("mimetype", t, string(mimetype), "application/epub+zip")
+	chapter, err := saved.ExtractSpineText(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals("chapter", t, chapter.Text, "New")
+	css, err := saved.ReadFile("style.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals("style.css", t, string(css), `p { margin: 0 }`)
+	for _, file := range reader.File {
+		if file.Name == "content.opf" {
+			for _, original := range book.ZipReader.File {
+				if original.Name == file.Name && original.CompressedSize64 != file.CompressedSize64 {
+					t.Logf("content.opf was recompressed")
+					t.Fail()
+				}
+			}
+		}
+	}
 }
 
 func assertMetadata(t *testing.T, metaData model.Metadata) {
@@ -56,7 +957,7 @@ func assertMetadata(t *testing.T, metaData model.Metadata) {
 	titles := *metaData.Titles
 	assertSize("titles size", t, len(titles), 1)
 	assertEquals("title.Title", t, titles[0].Title, "Test epub")
-	assertEquals("title.Language", t, titles[0].Language, "en")
+	assertEquals("title.Language", t, titles[0].Language.String(), "en")
 	assertEquals("title.Type", t, titles[0].Type, "main")
 	assertEquals("title.FileAs", t, titles[0].FileAs, "Test epub")
 
@@ -71,20 +972,24 @@ func assertMetadata(t *testing.T, metaData model.Metadata) {
 
 	languages := *metaData.Languages
 	assertSize("languages size", t, len(languages), 1)
-	assertEquals("language", t, languages[0], "en")
+	assertEquals("language", t, languages[0].String(), "en")
 
 	creators := *metaData.Creators
 	assertSize("creators size", t, len(creators), 1)
 	assertEquals("creators.FileAs", t, creators[0].FileAs, "Doe, John")
-	assertEquals("creators.Language", t, creators[0].Language, "")
+	assertEquals("creators.FileAsOrigin", t, string(creators[0].FileAsOrigin), "book")
+	assertEquals("creators.Language", t, creators[0].Language.String(), "")
 	assertEquals("creators.Name", t, creators[0].Name, "John, Doe")
 	assertEquals("creators.Role", t, creators[0].Role, "author")
 	assertEquals("creators.RawRole", t, creators[0].RawRole, "aut")
+	assertEquals("creators.RoleScheme", t, creators[0].RoleScheme, "marc:relators")
+	assertEquals("creators.RoleStatus", t, string(creators[0].RoleStatus), "resolved")
 
 	contributors := *metaData.Contributors
 	assertSize("contributors size", t, len(contributors), 1)
-	assertEquals("contributors.FileAs", t, contributors[0].FileAs, "")
-	assertEquals("contributors.Language", t, contributors[0].Language, "")
+	assertEquals("contributors.FileAs", t, contributors[0].FileAs, "GoLang")
+	assertEquals("contributors.FileAsOrigin", t, string(contributors[0].FileAsOrigin), "generated")
+	assertEquals("contributors.Language", t, contributors[0].Language.String(), "")
 	assertEquals("contributors.Name", t, contributors[0].Name, "GoLang")
 	assertEquals("contributors.Role", t, contributors[0].Role, "book producer")
 	assertEquals("contributors.RawRole", t, contributors[0].RawRole, "bkp")
@@ -92,21 +997,21 @@ func assertMetadata(t *testing.T, metaData model.Metadata) {
 	publishers := *metaData.Publishers
 	assertSize("publishers size", t, len(publishers), 1)
 	assertEquals("publishers.Text", t, publishers[0].Text, "Test Publisher")
-	assertEquals("publishers.Language", t, publishers[0].Language, "")
+	assertEquals("publishers.Language", t, publishers[0].Language.String(), "")
 
 	subjects := *metaData.Subjects
 	assertSize("subjects size", t, len(subjects), 2)
 	assertEquals("subjects[0].Text", t, subjects[0].Text, "Novel")
-	assertEquals("subjects[0].Language", t, subjects[0].Language, "")
+	assertEquals("subjects[0].Language", t, subjects[0].Language.String(), "")
 	assertEquals("subjects[1].Text", t, subjects[1].Text, "Comic science fiction")
-	assertEquals("subjects[1].Language", t, subjects[1].Language, "")
+	assertEquals("subjects[1].Language", t, subjects[1].Language.String(), "")
 
 	descriptions := *metaData.Descriptions
 	assertSize("descriptions size", t, len(descriptions), 1)
 	assertEquals("descriptions.Text", t, descriptions[0].Text, `an ordinary Earthling suddenly swept into a wild space adventure when his planet is slated for demolition. With nothing but a towel and a knack for making friends in strange places, Finn hitches rides across the galaxy alongside a quirky crew: a chronically pessimistic robot, a free-spirited alien guide, and a ship with an attitude.
 
 From cosmic dive bars to worlds with surreal rules, Finn navigates the chaos in search of purpose—or, at least, survival. Along the way, he learns one universal truth: when hitchhiking through the stars, never lose track of your towel.`)
-	assertEquals("descriptions.Language", t, descriptions[0].Language, "en")
+	assertEquals("descriptions.Language", t, descriptions[0].Language.String(), "en")
 
 	dates := *metaData.Dates
 	assertSize("dates size", t, len(dates), 1)
@@ -0,0 +1,327 @@
+package markdown
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mathieu-keller/epub-parser/builder"
+	"github.com/mathieu-keller/epub-parser/model"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultTOCDepth = 2
+
+type Options struct {
+	// TOCDepth is the deepest heading level in the table of contents, 2 if
+	// zero.
+	TOCDepth int
+	// NCX adds an EPUB 2 NCX table of contents.
+	NCX bool
+}
+
+// FrontMatter is the YAML front matter of the first chapter.
+type FrontMatter struct {
+	Title      string     `yaml:"title"`
+	Author     stringList `yaml:"author"`
+	Language   string     `yaml:"language"`
+	Identifier string     `yaml:"identifier"`
+	// Cover is the path of the cover image, relative to the directory.
+	Cover string `yaml:"cover"`
+}
+
+// stringList accepts a single string or a list of strings.
+type stringList []string
+
+func (list *stringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*list = stringList{node.Value}
+		return nil
+	}
+	var values []string
+	err := node.Decode(&values)
+	*list = values
+	return err
+}
+
+type heading struct {
+	level int
+	title string
+	id    string
+}
+
+type converter struct {
+	files    fs.FS
+	builder  *builder.Builder
+	markdown goldmark.Markdown
+	language string
+	depth    int
+	images   map[string]bool
+}
+
+// Convert creates an EPUB 3 book from the Markdown files (*.md) in the root
+// of files, in the order of their names. The front matter of the first file
+// holds the metadata of the book, the table of contents is made of the
+// headings. Images linked from the chapters are added to the book.
+func Convert(files fs.FS, options Options) (*model.Book, error) {
+	names, err := fs.Glob(files, "*.md")
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, errors.New("no markdown files found")
+	}
+	sort.Strings(names)
+	sources := make([][]byte, len(names))
+	for i, name := range names {
+		sources[i], err = fs.ReadFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	frontMatter, _, err := splitFrontMatter(sources[0])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", names[0], err)
+	}
+	if frontMatter.Language == "" {
+		frontMatter.Language = "en"
+	}
+	converter := &converter{
+		files:   files,
+		builder: builder.New(frontMatter.metadata()),
+		markdown: goldmark.New(
+			goldmark.WithExtensions(extension.GFM),
+			goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+			goldmark.WithRendererOptions(html.WithXHTML()),
+		),
+		language: frontMatter.Language,
+		depth:    options.TOCDepth,
+		images:   map[string]bool{},
+	}
+	if converter.depth <= 0 {
+		converter.depth = defaultTOCDepth
+	}
+	converter.builder.NCX = options.NCX
+	if frontMatter.Cover != "" {
+		data, err := fs.ReadFile(files, frontMatter.Cover)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := converter.builder.SetCover(path.Clean(frontMatter.Cover), data); err != nil {
+			return nil, err
+		}
+		converter.images[path.Clean(frontMatter.Cover)] = true
+	}
+	for i, name := range names {
+		_, body, err := splitFrontMatter(sources[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		entries, err := converter.chapter(name, body)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		converter.builder.TOC = append(converter.builder.TOC, entries...)
+	}
+	return converter.builder.Build()
+}
+
+// splitFrontMatter separates a leading "---" delimited YAML block from the
+// Markdown.
+func splitFrontMatter(source []byte) (FrontMatter, []byte, error) {
+	frontMatter := FrontMatter{}
+	normalized := bytes.ReplaceAll(source, []byte("\r\n"), []byte("\n"))
+	if !bytes.HasPrefix(normalized, []byte("---\n")) {
+		return frontMatter, source, nil
+	}
+	rest := normalized[len("---\n"):]
+	end := bytes.Index(rest, []byte("\n---\n"))
+	if end < 0 {
+		if !bytes.HasSuffix(rest, []byte("\n---")) {
+			return frontMatter, source, nil
+		}
+		end = len(rest) - len("\n---")
+	}
+	err := yaml.Unmarshal(rest[:end], &frontMatter)
+	body := rest[min(end+len("\n---\n"), len(rest)):]
+	return frontMatter, body, err
+}
+
+func (frontMatter FrontMatter) metadata() model.Metadata {
+	metadata := model.Metadata{
+		Titles:    &[]model.Title{{Title: frontMatter.Title}},
+		Languages: &[]model.Language{model.ParseLanguage(frontMatter.Language)},
+	}
+	if frontMatter.Title == "" {
+		metadata.Titles = nil
+	}
+	creators := make([]model.Creator, len(frontMatter.Author))
+	for i, author := range frontMatter.Author {
+		creators[i] = model.Creator{Name: author, RawRole: "aut"}
+	}
+	metadata.Creators = &creators
+	if frontMatter.Identifier != "" {
+		scheme, id, found := strings.Cut(frontMatter.Identifier, ":")
+		if !found {
+			scheme, id = "", frontMatter.Identifier
+		}
+		metadata.MainId = model.Identifier{Id: id, Scheme: scheme}
+		metadata.Identifiers = &[]model.Identifier{metadata.MainId}
+	}
+	return metadata
+}
+
+// chapter converts one Markdown file and returns its table of contents
+// entries.
+func (converter *converter) chapter(name string, source []byte) ([]model.TOCEntry, error) {
+	href := strings.TrimSuffix(name, path.Ext(name)) + ".xhtml"
+	document := converter.markdown.Parser().Parse(text.NewReader(source))
+	var headings []heading
+	err := ast.Walk(document, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := node.(type) {
+		case *ast.Heading:
+			id, _ := node.AttributeString("id")
+			idBytes, _ := id.([]byte)
+			headings = append(headings, heading{level: node.Level, title: nodeText(node, source), id: string(idBytes)})
+		case *ast.Image:
+			destination, ok := localPath(string(node.Destination))
+			if ok && !converter.images[destination] {
+				data, err := fs.ReadFile(converter.files, destination)
+				if err != nil {
+					return ast.WalkStop, err
+				}
+				if _, err := converter.builder.AddResource(destination, data); err != nil {
+					return ast.WalkStop, err
+				}
+				converter.images[destination] = true
+			}
+		case *ast.Link:
+			node.Destination = []byte(chapterLink(string(node.Destination)))
+		}
+		return ast.WalkContinue, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	body := bytes.Buffer{}
+	if err := converter.markdown.Renderer().Render(&body, source, document); err != nil {
+		return nil, err
+	}
+	title := strings.TrimSuffix(name, path.Ext(name))
+	if len(headings) > 0 {
+		title = headings[0].title
+	}
+	if _, err := converter.builder.AddChapter(href, "", converter.xhtml(title, body.Bytes())); err != nil {
+		return nil, err
+	}
+	entries := tocEntries(href, headings, converter.depth)
+	if len(entries) == 0 {
+		entries = []model.TOCEntry{{Title: title, Href: href}}
+	}
+	return entries, nil
+}
+
+func (converter *converter) xhtml(title string, body []byte) []byte {
+	document := bytes.Buffer{}
+	document.WriteString(`<?xml version="1.0" encoding="utf-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="` + escape(converter.language) + `" lang="` + escape(converter.language) + `">
+<head>
+  <title>` + escape(title) + `</title>
+</head>
+<body>
+`)
+	document.Write(body)
+	document.WriteString("</body>\n</html>\n")
+	return document.Bytes()
+}
+
+// tocEntries nests the headings of a chapter by level, leaving out headings
+// deeper than depth.
+func tocEntries(href string, headings []heading, depth int) []model.TOCEntry {
+	root := &model.TOCEntry{}
+	type open struct {
+		level int
+		entry *model.TOCEntry
+	}
+	stack := []open{{level: 0, entry: root}}
+	for _, heading := range headings {
+		if heading.level > depth {
+			continue
+		}
+		for len(stack) > 1 && stack[len(stack)-1].level >= heading.level {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1].entry
+		parent.Children = append(parent.Children, model.TOCEntry{Title: heading.title, Href: href, Fragment: heading.id})
+		stack = append(stack, open{level: heading.level, entry: &parent.Children[len(parent.Children)-1]})
+	}
+	return root.Children
+}
+
+func nodeText(node ast.Node, source []byte) string {
+	builder := strings.Builder{}
+	_ = ast.Walk(node, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			switch child := child.(type) {
+			case *ast.Text:
+				builder.Write(child.Value(source))
+				if child.SoftLineBreak() {
+					builder.WriteByte(' ')
+				}
+			case *ast.String:
+				builder.Write(child.Value)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(builder.String())
+}
+
+// localPath returns the path of a relative link destination, without
+// fragment or query.
+func localPath(destination string) (string, bool) {
+	link, err := url.Parse(destination)
+	if err != nil || link.Scheme != "" || link.Host != "" || link.Path == "" || path.IsAbs(link.Path) {
+		return "", false
+	}
+	cleaned := path.Clean(link.Path)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", false
+	}
+	return cleaned, true
+}
+
+// chapterLink points links to other Markdown chapters to their XHTML file.
+func chapterLink(destination string) string {
+	local, ok := localPath(destination)
+	if !ok || path.Ext(local) != ".md" {
+		return destination
+	}
+	link := strings.TrimSuffix(local, ".md") + ".xhtml"
+	if _, fragment, found := strings.Cut(destination, "#"); found {
+		link += "#" + fragment
+	}
+	return link
+}
+
+func escape(value string) string {
+	builder := strings.Builder{}
+	xml.EscapeText(&builder, []byte(value))
+	return builder.String()
+}
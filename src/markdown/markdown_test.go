@@ -0,0 +1,91 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func Test_convert(t *testing.T) {
+	files := fstest.MapFS{
+		"01-intro.md": {Data: []byte(`---
+title: Markdown Book
+author:
+  - Jane Doe
+  - John Roe
+language: de
+identifier: urn:isbn:9780000000000
+cover: images/cover.png
+---
+# Einleitung
+
+Siehe [Kapitel](02-chapter.md#details).
+
+## Hintergrund
+
+Text.
+`)},
+		"02-chapter.md": {Data: []byte(`# Kapitel *zwei*
+
+![Bild](images/figure.png)
+
+## Details
+
+### Zu tief
+`)},
+		"images/cover.png":  {Data: []byte("cover")},
+		"images/figure.png": {Data: []byte("figure")},
+	}
+	book, err := Convert(files, Options{NCX: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (*book.Metadata.Titles)[0].Title != "Markdown Book" || book.Metadata.MainId.Id != "isbn:9780000000000" {
+		t.Logf("unexpected metadata %v", book.Metadata)
+		t.Fail()
+	}
+	if len(*book.Metadata.Creators) != 2 || (*book.Metadata.Creators)[1].Name != "John Roe" {
+		t.Logf("unexpected creators %v", *book.Metadata.Creators)
+		t.Fail()
+	}
+	if (*book.Metadata.Languages)[0].String() != "de" {
+		t.Logf("unexpected language %v", *book.Metadata.Languages)
+		t.Fail()
+	}
+	if _, ok := book.ItemByHref("images/figure.png"); !ok {
+		t.Logf("image was not added")
+		t.Fail()
+	}
+	if cover, ok := book.ManifestItemByProperty("cover-image"); !ok || cover.Href != "images/cover.png" {
+		t.Logf("unexpected cover %v", cover)
+		t.Fail()
+	}
+	toc, err := book.ReadTOC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(toc) != 2 || toc[1].Title != "Kapitel zwei" || toc[1].Href != "02-chapter.xhtml" {
+		t.Logf("unexpected toc %v", toc)
+		t.Fail()
+	}
+	if len(toc[0].Children) != 1 || toc[0].Children[0].Fragment != "hintergrund" || len(toc[1].Children[0].Children) != 0 {
+		t.Logf("unexpected toc children %v", toc)
+		t.Fail()
+	}
+	chapter, err := book.ReadFile("EPUB/01-intro.xhtml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(chapter), `<a href="02-chapter.xhtml#details">Kapitel</a>`) || strings.Contains(string(chapter), "title:") {
+		t.Logf("unexpected chapter %s", chapter)
+		t.Fail()
+	}
+	text, err := book.ExtractSpineText(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text.Text != "Kapitel zwei\n\nDetails\n\nZu tief" {
+		t.Logf("unexpected text %q", text.Text)
+		t.Fail()
+	}
+}
@@ -0,0 +1,329 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var ErrUnsupported = errors.New("unsupported media type")
+
+// Duration reads the playing time of an MP3, MP4/M4A or Ogg (Vorbis, Opus)
+// file from its container headers, without decoding any audio. size is the
+// size of the whole file and is used to estimate constant bitrate MP3s.
+func Duration(reader io.Reader, size int64, mediaType string) (time.Duration, error) {
+	switch container(mediaType) {
+	case "mp3":
+		return mp3Duration(reader, size)
+	case "mp4":
+		return mp4Duration(reader)
+	case "ogg":
+		return oggDuration(reader)
+	}
+	return 0, ErrUnsupported
+}
+
+func container(mediaType string) string {
+	mediaType, _, _ = strings.Cut(strings.ToLower(mediaType), ";")
+	switch strings.TrimSpace(mediaType) {
+	case "audio/mpeg", "audio/mp3", "audio/mpeg3", "audio/x-mpeg-3":
+		return "mp3"
+	case "audio/mp4", "audio/x-m4a", "audio/m4a", "audio/x-m4b", "video/mp4", "video/x-m4v", "video/quicktime":
+		return "mp4"
+	case "audio/ogg", "audio/opus", "audio/vorbis", "video/ogg", "application/ogg":
+		return "ogg"
+	}
+	return ""
+}
+
+var mp3Bitrates = [2][3][16]int{
+	// MPEG 1: layer I, II, III
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+	},
+	// MPEG 2 and 2.5: layer I, II, III
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+	},
+}
+
+var mp3SampleRates = map[byte][3]int{
+	3: {44100, 48000, 32000}, // MPEG 1
+	2: {22050, 24000, 16000}, // MPEG 2
+	0: {11025, 12000, 8000},  // MPEG 2.5
+}
+
+type mp3Frame struct {
+	mpeg1           bool
+	layer           int
+	bitrate         int
+	sampleRate      int
+	samplesPerFrame int
+	mono            bool
+}
+
+func parseMp3Frame(header []byte) (mp3Frame, bool) {
+	if header[0] != 0xFF || header[1]&0xE0 != 0xE0 {
+		return mp3Frame{}, false
+	}
+	version := (header[1] >> 3) & 0x03
+	layerBits := (header[1] >> 1) & 0x03
+	bitrateIndex := header[2] >> 4
+	sampleRateIndex := (header[2] >> 2) & 0x03
+	rates, ok := mp3SampleRates[version]
+	if !ok || layerBits == 0 || bitrateIndex == 0 || bitrateIndex == 15 || sampleRateIndex == 3 {
+		return mp3Frame{}, false
+	}
+	frame := mp3Frame{
+		mpeg1:      version == 3,
+		layer:      4 - int(layerBits),
+		sampleRate: rates[sampleRateIndex],
+		mono:       header[3]>>6 == 3,
+	}
+	table := 1
+	if frame.mpeg1 {
+		table = 0
+	}
+	frame.bitrate = mp3Bitrates[table][frame.layer-1][bitrateIndex] * 1000
+	switch {
+	case frame.layer == 1:
+		frame.samplesPerFrame = 384
+	case frame.layer == 3 && !frame.mpeg1:
+		frame.samplesPerFrame = 576
+	default:
+		frame.samplesPerFrame = 1152
+	}
+	return frame, true
+}
+
+// mp3Duration uses the frame count of a Xing/Info or VBRI header if the file
+// has one, and estimates the duration from the bitrate of the first frame
+// otherwise.
+func mp3Duration(reader io.Reader, size int64) (time.Duration, error) {
+	buffered := bufio.NewReader(reader)
+	offset := int64(0)
+	header, err := buffered.Peek(10)
+	if err != nil {
+		return 0, fmt.Errorf("mp3: %w", err)
+	}
+	if string(header[:3]) == "ID3" {
+		tagSize := int64(header[6])<<21 | int64(header[7])<<14 | int64(header[8])<<7 | int64(header[9])
+		offset = 10 + tagSize
+		if header[5]&0x10 != 0 {
+			offset += 10
+		}
+		if _, err := buffered.Discard(int(offset)); err != nil {
+			return 0, fmt.Errorf("mp3: %w", err)
+		}
+	}
+
+	data := make([]byte, 16*1024)
+	n, err := io.ReadFull(buffered, data)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return 0, fmt.Errorf("mp3: %w", err)
+	}
+	data = data[:n]
+	for i := 0; i+4 <= len(data); i++ {
+		frame, ok := parseMp3Frame(data[i:])
+		if !ok {
+			continue
+		}
+		if frames, ok := mp3FrameCount(data[i:], frame); ok {
+			return samplesDuration(int64(frames)*int64(frame.samplesPerFrame), int64(frame.sampleRate)), nil
+		}
+		audioBytes := size - offset - int64(i)
+		if audioBytes <= 0 {
+			return 0, errors.New("mp3: file is truncated")
+		}
+		return time.Duration(float64(audioBytes*8) / float64(frame.bitrate) * float64(time.Second)), nil
+	}
+	return 0, errors.New("mp3: no frame header found")
+}
+
+func mp3FrameCount(frame []byte, header mp3Frame) (uint32, bool) {
+	sideInfo := 17
+	switch {
+	case header.mpeg1 && !header.mono:
+		sideInfo = 32
+	case !header.mpeg1 && header.mono:
+		sideInfo = 9
+	}
+	xing := 4 + sideInfo
+	if len(frame) >= xing+12 {
+		tag := string(frame[xing : xing+4])
+		flags := binary.BigEndian.Uint32(frame[xing+4:])
+		if (tag == "Xing" || tag == "Info") && flags&0x01 != 0 {
+			return binary.BigEndian.Uint32(frame[xing+8:]), true
+		}
+	}
+	const vbri = 4 + 32
+	if len(frame) >= vbri+18 && string(frame[vbri:vbri+4]) == "VBRI" {
+		return binary.BigEndian.Uint32(frame[vbri+14:]), true
+	}
+	return 0, false
+}
+
+// mp4Duration walks the top level boxes to the movie header (mvhd) inside
+// the moov box. Media data boxes are skipped without being read into memory.
+func mp4Duration(reader io.Reader) (time.Duration, error) {
+	for {
+		boxType, size, err := mp4BoxHeader(reader)
+		if err != nil {
+			if err == io.EOF {
+				return 0, errors.New("mp4: no moov box found")
+			}
+			return 0, fmt.Errorf("mp4: %w", err)
+		}
+		if boxType != "moov" {
+			if size < 0 {
+				return 0, errors.New("mp4: no moov box found")
+			}
+			if _, err := io.CopyN(io.Discard, reader, size); err != nil {
+				return 0, fmt.Errorf("mp4: %w", err)
+			}
+			continue
+		}
+		if size < 0 {
+			return mp4MovieHeader(reader)
+		}
+		return mp4MovieHeader(io.LimitReader(reader, size))
+	}
+}
+
+func mp4MovieHeader(reader io.Reader) (time.Duration, error) {
+	for {
+		boxType, size, err := mp4BoxHeader(reader)
+		if err != nil {
+			return 0, fmt.Errorf("mp4: no mvhd box found: %w", err)
+		}
+		if boxType != "mvhd" {
+			if size < 0 {
+				return 0, errors.New("mp4: no mvhd box found")
+			}
+			if _, err := io.CopyN(io.Discard, reader, size); err != nil {
+				return 0, fmt.Errorf("mp4: %w", err)
+			}
+			continue
+		}
+		header := make([]byte, 32)
+		if _, err := io.ReadFull(reader, header[:20]); err != nil {
+			return 0, fmt.Errorf("mp4: %w", err)
+		}
+		if header[0] == 1 {
+			if _, err := io.ReadFull(reader, header[20:]); err != nil {
+				return 0, fmt.Errorf("mp4: %w", err)
+			}
+			timescale := binary.BigEndian.Uint32(header[20:])
+			duration := binary.BigEndian.Uint64(header[24:])
+			return samplesDuration(int64(duration), int64(timescale)), nil
+		}
+		timescale := binary.BigEndian.Uint32(header[12:])
+		duration := binary.BigEndian.Uint32(header[16:])
+		return samplesDuration(int64(duration), int64(timescale)), nil
+	}
+}
+
+// mp4BoxHeader reads the header of the next box and returns its type and the
+// size of its content, or -1 if the box extends to the end of the file.
+func mp4BoxHeader(reader io.Reader) (string, int64, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return "", 0, err
+	}
+	size := int64(binary.BigEndian.Uint32(header))
+	boxType := string(header[4:])
+	switch size {
+	case 0:
+		return boxType, -1, nil
+	case 1:
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return "", 0, err
+		}
+		size = int64(binary.BigEndian.Uint64(header)) - 16
+	default:
+		size -= 8
+	}
+	if size < 0 {
+		return "", 0, fmt.Errorf("invalid size of box %q", boxType)
+	}
+	return boxType, size, nil
+}
+
+// oggDuration reads the sample rate from the identification header of the
+// first logical stream and the granule position of its last page.
+func oggDuration(reader io.Reader) (time.Duration, error) {
+	var serial uint32
+	var sampleRate, preSkip, granule int64
+	header := make([]byte, 27)
+	for page := 0; ; page++ {
+		_, err := io.ReadFull(reader, header)
+		if err == io.EOF && page > 0 {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("ogg: %w", err)
+		}
+		if string(header[:4]) != "OggS" {
+			return 0, errors.New("ogg: invalid page header")
+		}
+		segments := make([]byte, header[26])
+		if _, err := io.ReadFull(reader, segments); err != nil {
+			return 0, fmt.Errorf("ogg: %w", err)
+		}
+		length := int64(0)
+		for _, segment := range segments {
+			length += int64(segment)
+		}
+		pageSerial := binary.LittleEndian.Uint32(header[14:])
+		if page == 0 {
+			serial = pageSerial
+			packet := make([]byte, length)
+			if _, err := io.ReadFull(reader, packet); err != nil {
+				return 0, fmt.Errorf("ogg: %w", err)
+			}
+			sampleRate, preSkip, err = oggCodec(packet)
+			if err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if pageSerial == serial {
+			if position := int64(binary.LittleEndian.Uint64(header[6:])); position >= 0 {
+				granule = position
+			}
+		}
+		if _, err := io.CopyN(io.Discard, reader, length); err != nil {
+			return 0, fmt.Errorf("ogg: %w", err)
+		}
+	}
+	return samplesDuration(max(granule-preSkip, 0), sampleRate), nil
+}
+
+func oggCodec(packet []byte) (sampleRate int64, preSkip int64, err error) {
+	switch {
+	case len(packet) >= 16 && bytes.HasPrefix(packet, []byte("\x01vorbis")):
+		return int64(binary.LittleEndian.Uint32(packet[12:])), 0, nil
+	case len(packet) >= 12 && bytes.HasPrefix(packet, []byte("OpusHead")):
+		// Opus granule positions always count 48 kHz samples.
+		return 48000, int64(binary.LittleEndian.Uint16(packet[10:])), nil
+	}
+	return 0, 0, errors.New("ogg: unsupported codec")
+}
+
+func samplesDuration(samples int64, rate int64) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+	seconds := samples / rate
+	remainder := samples % rate
+	return time.Duration(seconds)*time.Second + time.Duration(remainder)*time.Second/time.Duration(rate)
+}
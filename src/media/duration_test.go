@@ -0,0 +1,117 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func mp3File(id3 bool, xingFrames uint32, audioBytes int) []byte {
+	var data []byte
+	if id3 {
+		// 10 byte header followed by a 100 byte tag (synchsafe size).
+		data = append(data, 'I', 'D', '3', 3, 0, 0, 0, 0, 0, 100)
+		data = append(data, make([]byte, 100)...)
+	}
+	// MPEG 1 layer III, 128 kbit/s, 44.1 kHz, stereo
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	if xingFrames > 0 {
+		copy(frame[36:], "Xing")
+		binary.BigEndian.PutUint32(frame[40:], 0x01)
+		binary.BigEndian.PutUint32(frame[44:], xingFrames)
+	}
+	data = append(data, frame...)
+	return append(data, make([]byte, audioBytes-len(frame))...)
+}
+
+func mp4Box(boxType string, content []byte) []byte {
+	box := binary.BigEndian.AppendUint32(nil, uint32(8+len(content)))
+	box = append(box, boxType...)
+	return append(box, content...)
+}
+
+func mp4File() []byte {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)   // timescale
+	binary.BigEndian.PutUint32(mvhd[16:], 754321) // duration
+	file := mp4Box("ftyp", []byte("M4A mp42isom"))
+	file = append(file, mp4Box("mdat", make([]byte, 4096))...)
+	return append(file, mp4Box("moov", append(mp4Box("udta", nil), mp4Box("mvhd", mvhd)...))...)
+}
+
+func oggPage(serial uint32, granule int64, packet []byte) []byte {
+	page := []byte("OggS")
+	page = append(page, 0, 0)
+	page = binary.LittleEndian.AppendUint64(page, uint64(granule))
+	page = binary.LittleEndian.AppendUint32(page, serial)
+	page = append(page, make([]byte, 8)...)
+	var segments []byte
+	for rest := len(packet); ; rest -= 255 {
+		if rest < 255 {
+			segments = append(segments, byte(rest))
+			break
+		}
+		segments = append(segments, 255)
+	}
+	page = append(page, byte(len(segments)))
+	page = append(page, segments...)
+	return append(page, packet...)
+}
+
+func oggVorbisFile() []byte {
+	identification := append([]byte("\x01vorbis"), make([]byte, 22)...)
+	binary.LittleEndian.PutUint32(identification[12:], 22050)
+	file := oggPage(7, 0, identification)
+	file = append(file, oggPage(7, 22050*60, make([]byte, 600))...)
+	file = append(file, oggPage(9, 99999999, make([]byte, 10))...)
+	return append(file, oggPage(7, 22050*90+11025, make([]byte, 300))...)
+}
+
+func oggOpusFile() []byte {
+	head := append([]byte("OpusHead"), 1, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint16(head[10:], 312)
+	file := oggPage(1, 0, head)
+	return append(file, oggPage(1, 48000*5+312, make([]byte, 40))...)
+}
+
+func Test_duration(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediaType string
+		data      []byte
+		expected  time.Duration
+	}{
+		{"mp3 xing", "audio/mpeg", mp3File(true, 3445, 64000), 89*time.Second + 991836734*time.Nanosecond},
+		{"mp3 cbr", "audio/mpeg", mp3File(true, 0, 160000), 10 * time.Second},
+		{"mp3 without id3", "audio/mp3", mp3File(false, 0, 16000), time.Second},
+		{"m4a", "audio/mp4", mp4File(), 754*time.Second + 321*time.Millisecond},
+		{"ogg vorbis", "audio/ogg", oggVorbisFile(), 90*time.Second + 500*time.Millisecond},
+		{"ogg opus", "audio/ogg; codecs=opus", oggOpusFile(), 5 * time.Second},
+	}
+	for _, test := range tests {
+		actual, err := Duration(bytes.NewReader(test.data), int64(len(test.data)), test.mediaType)
+		if err != nil || actual != test.expected {
+			t.Logf("%s: expected '%s' but is '%s' (%v)", test.name, test.expected, actual, err)
+			t.Fail()
+		}
+	}
+}
+
+func Test_duration_errors(t *testing.T) {
+	if _, err := Duration(bytes.NewReader(nil), 0, "audio/webm"); err != ErrUnsupported {
+		t.Logf("expected ErrUnsupported but is %v", err)
+		t.Fail()
+	}
+	for mediaType, data := range map[string][]byte{
+		"audio/mpeg": make([]byte, 500),
+		"video/mp4":  mp4Box("mdat", make([]byte, 10)),
+		"audio/ogg":  []byte("not an ogg file at all, no."),
+	} {
+		if _, err := Duration(bytes.NewReader(data), int64(len(data)), mediaType); err == nil {
+			t.Logf("%s: expected an error", mediaType)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package media
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mathieu-keller/epub-parser/model"
+	"golang.org/x/net/html"
+)
+
+type Inventory struct {
+	Resources []Resource
+	// Duration is the sum of all known resource durations.
+	Duration time.Duration
+	// ReadErrors are the spine documents and media overlays that could not be
+	// read. The spine items of the resources they use are incomplete.
+	ReadErrors []ReadError
+}
+
+// ReadError is a document that could not be read while cross-referencing the
+// resources.
+type ReadError struct {
+	Item model.ManifestItem
+	Err  error
+}
+
+func (readError ReadError) Error() string {
+	return readError.Item.Href + ": " + readError.Err.Error()
+}
+
+func (readError ReadError) Unwrap() error {
+	return readError.Err
+}
+
+type Resource struct {
+	Item      model.ManifestItem
+	MediaType string
+	Size      int64
+	Duration  time.Duration
+	// HasDuration is false if the container is not supported or its headers
+	// could not be read. DurationError holds the reason in the latter case.
+	HasDuration   bool
+	DurationError error
+	// SpineItems are the ids of the spine documents that embed the resource
+	// or reference it from their media overlay.
+	SpineItems []string
+	// MediaOverlays are the ids of the SMIL documents that reference it.
+	MediaOverlays []string
+}
+
+func IsAudio(item model.ManifestItem) bool {
+	return strings.HasPrefix(item.MediaType, "audio/")
+}
+
+func IsVideo(item model.ManifestItem) bool {
+	return strings.HasPrefix(item.MediaType, "video/")
+}
+
+// NewInventory lists the audio and video resources of the manifest in
+// manifest order, reads their durations and cross-references them with the
+// spine documents and media overlays that use them. Documents that cannot be
+// read are recorded in ReadErrors and skipped.
+func NewInventory(book *model.Book) *Inventory {
+	inventory := &Inventory{}
+	index := make(map[string]int)
+	if book.Manifest != nil {
+		for _, item := range *book.Manifest {
+			if !IsAudio(item) && !IsVideo(item) {
+				continue
+			}
+			resource := Resource{Item: item, MediaType: item.MediaType}
+			resource.Size, _ = book.ItemSize(item)
+			resource.Duration, resource.DurationError = readDuration(book, item, resource.Size)
+			if resource.DurationError == nil {
+				resource.HasDuration = true
+				inventory.Duration += resource.Duration
+			} else if errors.Is(resource.DurationError, ErrUnsupported) {
+				resource.DurationError = nil
+			}
+			index[model.ResolveHref("", item.Href)] = len(inventory.Resources)
+			inventory.Resources = append(inventory.Resources, resource)
+		}
+	}
+	if len(inventory.Resources) == 0 || book.Spine == nil {
+		return inventory
+	}
+
+	overlays := make(map[string]bool)
+	for _, spineItem := range *book.Spine {
+		document, ok := book.ManifestItem(spineItem.IdRef)
+		if !ok {
+			continue
+		}
+		if document.IsXHTML() {
+			root, err := book.ReadItemHTML(document)
+			if err != nil {
+				inventory.ReadErrors = append(inventory.ReadErrors, ReadError{Item: document, Err: err})
+			} else {
+				for _, src := range embeddedMedia(root) {
+					if i, ok := index[model.ResolveHref(document.Href, src)]; ok {
+						inventory.Resources[i].SpineItems = appendUnique(inventory.Resources[i].SpineItems, document.Id)
+					}
+				}
+			}
+		}
+		smil, ok := book.MediaOverlayFor(document)
+		if !ok {
+			continue
+		}
+		overlay, err := book.ReadMediaOverlay(smil)
+		if err != nil {
+			inventory.ReadErrors = append(inventory.ReadErrors, ReadError{Item: smil, Err: err})
+			continue
+		}
+		for _, par := range overlay.Pars {
+			if i, ok := index[par.AudioHref]; ok {
+				inventory.Resources[i].SpineItems = appendUnique(inventory.Resources[i].SpineItems, document.Id)
+				if !overlays[smil.Id+"\x00"+par.AudioHref] {
+					overlays[smil.Id+"\x00"+par.AudioHref] = true
+					inventory.Resources[i].MediaOverlays = append(inventory.Resources[i].MediaOverlays, smil.Id)
+				}
+			}
+		}
+	}
+	return inventory
+}
+
+// ForSpineItem returns the resources used by the spine document with the
+// given manifest id.
+func (inventory *Inventory) ForSpineItem(id string) []Resource {
+	var resources []Resource
+	for _, resource := range inventory.Resources {
+		for _, spineItem := range resource.SpineItems {
+			if spineItem == id {
+				resources = append(resources, resource)
+				break
+			}
+		}
+	}
+	return resources
+}
+
+func readDuration(book *model.Book, item model.ManifestItem, size int64) (time.Duration, error) {
+	if container(item.MediaType) == "" {
+		return 0, ErrUnsupported
+	}
+	reader, err := book.OpenItem(item)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+	return Duration(reader, size, item.MediaType)
+}
+
+func embeddedMedia(node *html.Node) []string {
+	var sources []string
+	if node.Type == html.ElementNode {
+		switch node.Data {
+		case "audio", "video", "source", "track", "embed":
+			for _, attr := range node.Attr {
+				if attr.Key == "src" && attr.Val != "" {
+					sources = append(sources, attr.Val)
+				}
+			}
+		case "object":
+			for _, attr := range node.Attr {
+				if attr.Key == "data" && attr.Val != "" {
+					sources = append(sources, attr.Val)
+				}
+			}
+		}
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		sources = append(sources, embeddedMedia(child)...)
+	}
+	return sources
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,120 @@
+package media
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mathieu-keller/epub-parser/internal/epubtest"
+)
+
+const containerXML = `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>`
+
+const opf = `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title>Audiobook</dc:title>
+    <dc:language>en</dc:language>
+  </metadata>
+  <manifest>
+    <item id="c1" href="text/c1.xhtml" media-type="application/xhtml+xml" media-overlay="c1-smil"/>
+    <item id="c2" href="text/c2.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c1-smil" href="smil/c1.smil" media-type="application/smil+xml"/>
+    <item id="a1" href="audio/track%201.mp3" media-type="audio/mpeg"/>
+    <item id="a2" href="audio/track2.m4a" media-type="audio/mp4"/>
+    <item id="v1" href="video/clip.webm" media-type="video/webm"/>
+  </manifest>
+  <spine>
+    <itemref idref="c1"/>
+    <itemref idref="c2"/>
+  </spine>
+</package>`
+
+const smil = `<smil xmlns="http://www.w3.org/ns/SMIL" version="3.0"><body>
+<par id="p1"><text src="../text/c1.xhtml#s1"/><audio src="../audio/track%201.mp3" clipBegin="0s" clipEnd="5s"/></par>
+<par id="p2"><text src="../text/c1.xhtml#s2"/><audio src="../audio/track%201.mp3" clipBegin="5s" clipEnd="9s"/></par>
+</body></smil>`
+
+func Test_inventory(t *testing.T) {
+	book := epubtest.Open(t, map[string]string{
+		"META-INF/container.xml":  containerXML,
+		"OEBPS/content.opf":       opf,
+		"OEBPS/smil/c1.smil":      smil,
+		"OEBPS/text/c1.xhtml":     `<html xmlns="http://www.w3.org/1999/xhtml"><body><p id="s1">One</p><p id="s2">Two</p></body></html>`,
+		"OEBPS/text/c2.xhtml":     `<html xmlns="http://www.w3.org/1999/xhtml"><body><audio controls="controls"><source src="../audio/track2.m4a"/></audio><video src="../video/clip.webm"/></body></html>`,
+		"OEBPS/audio/track 1.mp3": string(mp3File(true, 0, 160000)),
+		"OEBPS/audio/track2.m4a":  string(mp4File()),
+		"OEBPS/video/clip.webm":   "webm",
+	})
+	inventory := NewInventory(book)
+	if len(inventory.Resources) != 3 {
+		t.Fatalf("expected 3 resources but found %d", len(inventory.Resources))
+	}
+	mp3, m4a, webm := inventory.Resources[0], inventory.Resources[1], inventory.Resources[2]
+	assertResource(t, mp3, "a1", 160110, true, 10*time.Second, []string{"c1"}, []string{"c1-smil"})
+	assertResource(t, m4a, "a2", int64(len(mp4File())), true, 754*time.Second+321*time.Millisecond, []string{"c2"}, nil)
+	assertResource(t, webm, "v1", 4, false, 0, []string{"c2"}, nil)
+	if webm.DurationError != nil {
+		t.Logf("unsupported containers should not report an error: %v", webm.DurationError)
+		t.Fail()
+	}
+	if inventory.Duration != 764*time.Second+321*time.Millisecond {
+		t.Logf("expected total duration '764.321s' but is '%s'", inventory.Duration)
+		t.Fail()
+	}
+	if resources := inventory.ForSpineItem("c2"); len(resources) != 2 || resources[0].Item.Id != "a2" {
+		t.Logf("expected track2 and clip for c2 but found %v", resources)
+		t.Fail()
+	}
+}
+
+func Test_inventory_with_unreadable_documents(t *testing.T) {
+	book := epubtest.OpenReader(t, epubtest.ZipEntries(t, []epubtest.Entry{
+		{Name: "META-INF/container.xml", Content: containerXML},
+		{Name: "OEBPS/content.opf", Content: opf},
+		{Name: "OEBPS/smil/c1.smil", Content: "<smil><body><par>"},
+		{Name: "OEBPS/text/c1.xhtml", Content: `<html xmlns="http://www.w3.org/1999/xhtml"><body/></html>`},
+		// An unknown compression method makes the chapter unreadable.
+		{Name: "OEBPS/text/c2.xhtml", Content: "<html/>", Method: 99},
+		{Name: "OEBPS/audio/track 1.mp3", Content: string(mp3File(true, 0, 160000))},
+		{Name: "OEBPS/audio/track2.m4a", Content: string(mp4File())},
+	}))
+	inventory := NewInventory(book)
+	if len(inventory.Resources) != 3 || inventory.Duration != 764*time.Second+321*time.Millisecond {
+		t.Logf("expected 3 resources with durations but found %d, '%s'", len(inventory.Resources), inventory.Duration)
+		t.Fail()
+	}
+	if len(inventory.ReadErrors) != 2 || inventory.ReadErrors[0].Item.Id != "c1-smil" || inventory.ReadErrors[1].Item.Id != "c2" {
+		t.Logf("expected read errors for c1-smil and c2 but found %v", inventory.ReadErrors)
+		t.Fail()
+	}
+}
+
+func assertResource(t *testing.T, resource Resource, id string, size int64, hasDuration bool, duration time.Duration, spineItems []string, overlays []string) {
+	if resource.Item.Id != id || resource.Size != size || resource.HasDuration != hasDuration || resource.Duration != duration {
+		t.Logf("expected %s with %d bytes and duration '%s' (%t) but is %s with %d bytes and '%s' (%t, %v)",
+			id, size, duration, hasDuration, resource.Item.Id, resource.Size, resource.Duration, resource.HasDuration, resource.DurationError)
+		t.Fail()
+	}
+	if !equalStrings(resource.SpineItems, spineItems) || !equalStrings(resource.MediaOverlays, overlays) {
+		t.Logf("%s: expected spine items %v and overlays %v but is %v and %v", id, spineItems, overlays, resource.SpineItems, resource.MediaOverlays)
+		t.Fail()
+	}
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package model
+
+import "strings"
+
+type Accessibility struct {
+	AccessModes           []string
+	AccessModesSufficient [][]string
+	Features              []string
+	Hazards               []string
+	APIs                  []string
+	Controls              []string
+	Summary               string
+	ConformsTo            []string
+	CertifiedBy           string
+	CertifierCredential   string
+	CertifierReport       string
+	InvalidValues         []InvalidValue
+}
+
+type InvalidValue struct {
+	Property string
+	Value    string
+}
+
+var accessModes = vocabulary(
+	"auditory", "chartOnVisual", "chemOnVisual", "colorDependent", "diagramOnVisual",
+	"mathOnVisual", "musicOnVisual", "tactile", "textOnVisual", "textual", "visual",
+)
+
+var accessModesSufficient = vocabulary("auditory", "tactile", "textual", "visual")
+
+var accessibilityFeatures = vocabulary(
+	"alternativeText", "annotations", "ARIA", "audioDescription", "bookmarks", "braille",
+	"captions", "ChemML", "closedCaptions", "describedMath", "displayTransformability",
+	"fullRubyAnnotations", "highContrastAudio", "highContrastDisplay", "horizontalWriting",
+	"index", "largePrint", "latex", "latex-chemistry", "longDescription", "MathML",
+	"MathML-chemistry", "none", "openCaptions", "pageBreakMarkers", "pageNavigation",
+	"printPageNumbers", "readingOrder", "rubyAnnotations", "signLanguage",
+	"structuralNavigation", "synchronizedAudioText", "tableOfContents", "tactileGraphic",
+	"tactileObject", "taggedPDF", "timingControl", "transcript", "ttsMarkup", "unlocked",
+	"verticalWriting", "withAdditionalWordSegmentation", "withoutAdditionalWordSegmentation",
+)
+
+var accessibilityHazards = vocabulary(
+	"flashing", "motionSimulation", "sound", "none", "unknown",
+	"noFlashingHazard", "noMotionSimulationHazard", "noSoundHazard",
+	"unknownFlashingHazard", "unknownMotionSimulationHazard", "unknownSoundHazard",
+)
+
+var accessibilityAPIs = vocabulary(
+	"ARIA", "AndroidAccessibility", "ATK", "AT-SPI", "BlackberryAccessibility",
+	"iAccessible2", "iOSAccessibility", "JavaAccessibility", "MacOSXAccessibility",
+	"MSAA", "UIAutomation",
+)
+
+var accessibilityControls = vocabulary(
+	"fullAudioControl", "fullKeyboardControl", "fullMouseControl", "fullSwitchControl",
+	"fullTouchControl", "fullVideoControl", "fullVoiceControl",
+)
+
+// Add stores the value of an accessibility property. Values outside of the
+// controlled vocabularies are kept in InvalidValues instead. It returns false
+// if the property is not an accessibility property.
+func (accessibility *Accessibility) Add(property string, value string) bool {
+	value = strings.TrimSpace(value)
+	switch property {
+	case "schema:accessMode":
+		accessibility.AccessModes = accessibility.addTerm(accessibility.AccessModes, accessModes, property, value)
+	case "schema:accessModeSufficient":
+		var modes []string
+		for _, mode := range strings.Split(value, ",") {
+			term, ok := accessModesSufficient[strings.ToLower(strings.TrimSpace(mode))]
+			if !ok {
+				accessibility.InvalidValues = append(accessibility.InvalidValues, InvalidValue{Property: property, Value: value})
+				return true
+			}
+			modes = append(modes, term)
+		}
+		accessibility.AccessModesSufficient = append(accessibility.AccessModesSufficient, modes)
+	case "schema:accessibilityFeature":
+		accessibility.Features = accessibility.addTerm(accessibility.Features, accessibilityFeatures, property, value)
+	case "schema:accessibilityHazard":
+		accessibility.Hazards = accessibility.addTerm(accessibility.Hazards, accessibilityHazards, property, value)
+	case "schema:accessibilityAPI":
+		accessibility.APIs = accessibility.addTerm(accessibility.APIs, accessibilityAPIs, property, value)
+	case "schema:accessibilityControl":
+		accessibility.Controls = accessibility.addTerm(accessibility.Controls, accessibilityControls, property, value)
+	case "schema:accessibilitySummary":
+		accessibility.Summary = value
+	case "dcterms:conformsTo":
+		accessibility.ConformsTo = append(accessibility.ConformsTo, value)
+	case "a11y:certifiedBy":
+		accessibility.CertifiedBy = value
+	case "a11y:certifierCredential":
+		accessibility.CertifierCredential = value
+	case "a11y:certifierReport":
+		accessibility.CertifierReport = value
+	default:
+		return false
+	}
+	return true
+}
+
+func (accessibility *Accessibility) addTerm(terms []string, vocabulary map[string]string, property string, value string) []string {
+	term, ok := vocabulary[strings.ToLower(value)]
+	if !ok {
+		accessibility.InvalidValues = append(accessibility.InvalidValues, InvalidValue{Property: property, Value: value})
+		return terms
+	}
+	return append(terms, term)
+}
+
+func (accessibility *Accessibility) HasFeature(feature string) bool {
+	for _, term := range accessibility.Features {
+		if strings.EqualFold(term, feature) {
+			return true
+		}
+	}
+	return false
+}
+
+func vocabulary(terms ...string) map[string]string {
+	vocabulary := make(map[string]string, len(terms))
+	for _, term := range terms {
+		vocabulary[strings.ToLower(term)] = term
+	}
+	return vocabulary
+}
@@ -2,16 +2,89 @@ package model
 
 import (
 	"archive/zip"
+	"bytes"
 	"encoding/xml"
 	"fmt"
 	"io"
+	"maps"
+	"net/url"
 	"path"
+
+	"golang.org/x/net/html"
 )
 
 type Book struct {
-	Metadata  Metadata
-	Container Container
-	ZipReader *zip.Reader
+	Version                  string
+	Metadata                 Metadata
+	Manifest                 *[]ManifestItem
+	Spine                    *[]SpineItem
+	PageProgressionDirection Direction
+	Rendition                Rendition
+	MediaOverlays            MediaOverlays
+	Landmarks                []Landmark
+	Container                Container
+	ZipReader                *zip.Reader
+	// files holds the content of files that were added or changed in memory,
+	// keyed by their path inside the zip file.
+	files map[string][]byte
+}
+
+func (book *Book) ManifestItem(id string) (ManifestItem, bool) {
+	if book.Manifest != nil {
+		for _, item := range *book.Manifest {
+			if item.Id == id {
+				return item, true
+			}
+		}
+	}
+	return ManifestItem{}, false
+}
+
+func (book *Book) ManifestItemByProperty(property string) (ManifestItem, bool) {
+	if book.Manifest != nil {
+		for _, item := range *book.Manifest {
+			if item.HasProperty(property) {
+				return item, true
+			}
+		}
+	}
+	return ManifestItem{}, false
+}
+
+func (book *Book) SpineDocuments() []ManifestItem {
+	var documents []ManifestItem
+	if book.Spine != nil {
+		for _, spineItem := range *book.Spine {
+			if item, ok := book.ManifestItem(spineItem.IdRef); ok {
+				documents = append(documents, item)
+			}
+		}
+	}
+	return documents
+}
+
+func (book *Book) OpenItem(item ManifestItem) (io.ReadCloser, error) {
+	return book.open(book.ItemPath(item))
+}
+
+// ItemPath returns the path of a manifest item inside the zip file.
+func (book *Book) ItemPath(item ManifestItem) string {
+	href := item.Href
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		href = unescaped
+	}
+	return book.getFileFromRootPath(href)
+}
+
+// ReadItemHTML parses an (X)HTML manifest item. Well-formed documents are
+// parsed as XML, anything else like a browser would parse HTML.
+func (book *Book) ReadItemHTML(item ManifestItem) (*html.Node, error) {
+	reader, err := book.OpenItem(item)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return parseDocument(reader)
 }
 
 func (book *Book) Open(fileName string) (io.ReadCloser, error) {
@@ -32,11 +105,71 @@ func (book *Book) ReadXML(fileName string, targetStruct interface{}) error {
 	return dec.Decode(targetStruct)
 }
 
+// ItemSize returns the uncompressed size of a manifest item in bytes.
+func (book *Book) ItemSize(item ManifestItem) (int64, bool) {
+	fileName := book.ItemPath(item)
+	if data, ok := book.files[fileName]; ok {
+		return int64(len(data)), true
+	}
+	if file := book.zipFile(fileName); file != nil {
+		return int64(file.UncompressedSize64), true
+	}
+	return 0, false
+}
+
+// ReadFile returns the content of a file inside the zip file, including
+// changes made with WriteFile.
+func (book *Book) ReadFile(fileName string) ([]byte, error) {
+	reader, err := book.open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// WriteFile replaces or adds a file inside the zip file. The change is kept
+// in memory until the book is written.
+func (book *Book) WriteFile(fileName string, data []byte) {
+	if book.files == nil {
+		book.files = make(map[string][]byte)
+	}
+	book.files[fileName] = data
+}
+
+// ModifiedFiles returns the paths of the files changed with WriteFile.
+func (book *Book) ModifiedFiles() []string {
+	return sortedKeys(book.files)
+}
+
+func (book *Book) IsModified(fileName string) bool {
+	_, ok := book.files[fileName]
+	return ok
+}
+
+// Copy returns a copy of the book with its own changed files, so that
+// WriteFile on the copy leaves the book unchanged. Both share the zip file
+// and the metadata slices, which must be replaced rather than changed in
+// place.
+func (book *Book) Copy() *Book {
+	copied := *book
+	copied.files = maps.Clone(book.files)
+	return &copied
+}
+
+func (book *Book) exists(fileName string) bool {
+	if _, ok := book.files[fileName]; ok {
+		return true
+	}
+	return book.zipFile(fileName) != nil
+}
+
 func (book *Book) open(fileName string) (io.ReadCloser, error) {
-	for _, file := range book.ZipReader.File {
-		if file.Name == fileName {
-			return file.Open()
-		}
+	if data, ok := book.files[fileName]; ok {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	if file := book.zipFile(fileName); file != nil {
+		return file.Open()
 	}
 	return nil, fmt.Errorf("file %s not exist", fileName)
 }
@@ -0,0 +1,227 @@
+package model
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mathieu-keller/epub-parser/cfi"
+	"golang.org/x/net/html"
+)
+
+// Location is the node a CFI points to in a spine document.
+type Location struct {
+	SpineIndex int
+	Item       ManifestItem
+	cfi.Location
+}
+
+// ResolveCFI resolves a CFI, or the start of a CFI range, to the spine
+// document and node it points to.
+func (book *Book) ResolveCFI(value cfi.CFI) (Location, error) {
+	return book.resolveCFIPath(value.StartPoint().Path)
+}
+
+// ResolveCFIRange resolves the start and end of a CFI range. Both are equal
+// for a point CFI.
+func (book *Book) ResolveCFIRange(value cfi.CFI) (Location, Location, error) {
+	start, err := book.resolveCFIPath(value.StartPoint().Path)
+	if err != nil {
+		return Location{}, Location{}, err
+	}
+	end, err := book.resolveCFIPath(value.EndPoint().Path)
+	return start, end, err
+}
+
+func (book *Book) resolveCFIPath(path cfi.Path) (Location, error) {
+	packageSteps, local, err := cfi.SplitIndirection(path)
+	if err != nil {
+		return Location{}, err
+	}
+	if len(packageSteps) != 2 || packageSteps[1].Index < 2 || packageSteps[1].Index%2 != 0 || book.Spine == nil {
+		return Location{}, errors.New("cfi does not point to a spine item")
+	}
+	spineStep, err := book.spineStep()
+	if err != nil {
+		return Location{}, err
+	}
+	if packageSteps[0].Index != spineStep {
+		return Location{}, fmt.Errorf("cfi step /%d does not point to the spine at /%d", packageSteps[0].Index, spineStep)
+	}
+	spineIndex := packageSteps[1].Index/2 - 1
+	if id := packageSteps[1].Id; id != "" && (spineIndex >= len(*book.Spine) || (*book.Spine)[spineIndex].Id != id) {
+		for i, spineItem := range *book.Spine {
+			if spineItem.Id == id {
+				spineIndex = i
+			}
+		}
+	}
+	item, err := book.spineDocument(spineIndex)
+	if err != nil {
+		return Location{}, err
+	}
+	root, err := book.ReadItemHTML(item)
+	if err != nil {
+		return Location{}, err
+	}
+	location, err := cfi.Resolve(root, local)
+	if err != nil {
+		return Location{}, err
+	}
+	return Location{SpineIndex: spineIndex, Item: item, Location: location}, nil
+}
+
+// spineStep returns the step of the spine element in the package document,
+// which usually follows metadata and manifest.
+func (book *Book) spineStep() (int, error) {
+	reader, err := book.open(book.Container.Rootfile.Path)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+	decoder := xml.NewDecoder(reader)
+	depth, step := 0, 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return 0, errors.New("package document has no spine")
+		}
+		if err != nil {
+			return 0, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 {
+				step += 2
+				if token.Name.Local == "spine" {
+					return step, nil
+				}
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+// spineSteps returns the steps from the package document to the itemref of
+// a spine item.
+func (book *Book) spineSteps(spineStep int, spineIndex int) []cfi.Step {
+	itemref := cfi.Step{Index: (spineIndex + 1) * 2}
+	if book.Spine != nil && spineIndex < len(*book.Spine) {
+		itemref.Id = (*book.Spine)[spineIndex].Id
+	}
+	return []cfi.Step{{Index: spineStep}, itemref}
+}
+
+// TextCFI returns the CFI of a byte offset in the extracted text of a spine
+// document.
+func (book *Book) TextCFI(chapter ChapterText, offset int) (cfi.CFI, error) {
+	spineStep, err := book.spineStep()
+	if err != nil {
+		return cfi.CFI{}, err
+	}
+	return book.textCFI(chapter, offset, spineStep)
+}
+
+// textCFI is TextCFI with the step of the spine element already known, so
+// that callers generating many CFIs read the package document only once.
+func (book *Book) textCFI(chapter ChapterText, offset int, spineStep int) (cfi.CFI, error) {
+	if chapter.SpineIndex < 0 {
+		return cfi.CFI{}, errors.New("text is not part of the spine")
+	}
+	if offset < 0 || offset > len(chapter.Text) {
+		return cfi.CFI{}, fmt.Errorf("offset %d is outside of the text", offset)
+	}
+	node, nodeOffset, ok := chapter.nodeAt(offset)
+	if !ok {
+		// The document has no text, point to its body.
+		body := FindElement(chapter.root, func(node *html.Node) bool { return node.Data == "body" })
+		if body == nil {
+			return cfi.CFI{}, errors.New("document has no body")
+		}
+		return book.stepCFI(spineStep, chapter.SpineIndex, cfi.NewPath(body, 0)), nil
+	}
+	return book.stepCFI(spineStep, chapter.SpineIndex, cfi.NewPath(node, nodeOffset)), nil
+}
+
+// ElementCFI returns the CFI of the element with the given id in a spine
+// document.
+func (book *Book) ElementCFI(spineIndex int, id string) (cfi.CFI, error) {
+	item, err := book.spineDocument(spineIndex)
+	if err != nil {
+		return cfi.CFI{}, err
+	}
+	root, err := book.ReadItemHTML(item)
+	if err != nil {
+		return cfi.CFI{}, err
+	}
+	element := cfi.ElementById(root, id)
+	if element == nil {
+		return cfi.CFI{}, fmt.Errorf("element %q does not exist in %s", id, item.Href)
+	}
+	return book.documentCFI(spineIndex, cfi.NewPath(element, 0))
+}
+
+// ProgressCFI converts a bookmark given as a fraction between 0 and 1 of the
+// text of a spine document into a CFI. The position is moved to the start of
+// the word it falls into.
+func (book *Book) ProgressCFI(spineIndex int, progress float64) (cfi.CFI, error) {
+	if progress < 0 || progress > 1 {
+		return cfi.CFI{}, fmt.Errorf("progress %v is not between 0 and 1", progress)
+	}
+	chapter, err := book.ExtractSpineText(spineIndex)
+	if err != nil {
+		return cfi.CFI{}, err
+	}
+	runes := utf8.RuneCountInString(chapter.Text)
+	target := int(progress * float64(runes))
+	offset := len(chapter.Text)
+	for i := range chapter.Text {
+		if target == 0 {
+			offset = i
+			break
+		}
+		target--
+	}
+	for offset > 0 && offset < len(chapter.Text) {
+		previous, size := utf8.DecodeLastRuneInString(chapter.Text[:offset])
+		if unicode.IsSpace(previous) {
+			break
+		}
+		offset -= size
+	}
+	return book.TextCFI(chapter, offset)
+}
+
+// SpineIndex returns the position of the spine item referencing the manifest
+// item with the given id.
+func (book *Book) SpineIndex(idref string) (int, bool) {
+	if book.Spine != nil {
+		for i, spineItem := range *book.Spine {
+			if spineItem.IdRef == idref {
+				return i, true
+			}
+		}
+	}
+	return -1, false
+}
+
+// documentCFI prefixes a path inside a content document with the steps to
+// its spine item.
+func (book *Book) documentCFI(spineIndex int, local cfi.Path) (cfi.CFI, error) {
+	spineStep, err := book.spineStep()
+	if err != nil {
+		return cfi.CFI{}, err
+	}
+	return book.stepCFI(spineStep, spineIndex, local), nil
+}
+
+// stepCFI is documentCFI with the step of the spine element already known.
+func (book *Book) stepCFI(spineStep int, spineIndex int, local cfi.Path) cfi.CFI {
+	local.Steps[0].Indirection = true
+	return cfi.CFI{Path: cfi.Path{Steps: append(book.spineSteps(spineStep, spineIndex), local.Steps...), Offset: local.Offset}}
+}
@@ -0,0 +1,62 @@
+package model
+
+type Direction string
+
+const (
+	DirectionLTR     Direction = "ltr"
+	DirectionRTL     Direction = "rtl"
+	DirectionAuto    Direction = "auto"
+	DirectionDefault Direction = "default"
+)
+
+var rightToLeftScripts = map[string]bool{
+	"Arab": true, "Hebr": true, "Thaa": true, "Syrc": true, "Nkoo": true,
+	"Adlm": true, "Mand": true, "Samr": true, "Rohg": true,
+}
+
+func ParseDirection(dir string) Direction {
+	switch Direction(dir) {
+	case DirectionLTR, DirectionRTL, DirectionAuto, DirectionDefault:
+		return Direction(dir)
+	}
+	return ""
+}
+
+// Or returns direction, or fallback if no direction is set. It is used to
+// inherit the dir attribute of an enclosing element.
+func (direction Direction) Or(fallback Direction) Direction {
+	if direction == "" {
+		return fallback
+	}
+	return direction
+}
+
+// Direction returns the writing direction of the script the language is
+// usually written in.
+func (lang Language) Direction() Direction {
+	if !lang.Valid {
+		return ""
+	}
+	script, _ := lang.Tag.Script()
+	if rightToLeftScripts[script.String()] {
+		return DirectionRTL
+	}
+	return DirectionLTR
+}
+
+// ReadingDirection returns the page progression direction of the book. If
+// the spine leaves it to the reading system, it is derived from the first
+// dc:language.
+func (book *Book) ReadingDirection() Direction {
+	if book.PageProgressionDirection == DirectionLTR || book.PageProgressionDirection == DirectionRTL {
+		return book.PageProgressionDirection
+	}
+	if book.Metadata.Languages != nil {
+		for _, lang := range *book.Metadata.Languages {
+			if direction := lang.Direction(); direction != "" {
+				return direction
+			}
+		}
+	}
+	return DirectionLTR
+}
@@ -0,0 +1,65 @@
+package model
+
+import "strings"
+
+const displayOptionsPath = "META-INF/com.apple.ibooks.display-options.xml"
+
+type DisplayOptions struct {
+	Platforms []DisplayOptionsPlatform `xml:"platform"`
+}
+
+type DisplayOptionsPlatform struct {
+	Name    string          `xml:"name,attr"`
+	Options []DisplayOption `xml:"option"`
+}
+
+type DisplayOption struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+// ReadDisplayOptions reads the rendition from Apple's iBooks
+// display-options.xml, which older fixed-layout books use instead of the
+// rendition:* metadata. Books without the file return an empty rendition.
+func (book *Book) ReadDisplayOptions() (Rendition, error) {
+	rendition := Rendition{}
+	if !book.exists(displayOptionsPath) {
+		return rendition, nil
+	}
+	displayOptions := DisplayOptions{}
+	err := book.ReadXML(displayOptionsPath, &displayOptions)
+	if err != nil {
+		return rendition, err
+	}
+	for _, platform := range displayOptions.Platforms {
+		if platform.Name != "*" && platform.Name != "ipad" && platform.Name != "" {
+			continue
+		}
+		for _, option := range platform.Options {
+			value := strings.TrimSpace(option.Value)
+			switch option.Name {
+			case "fixed-layout":
+				if value == "true" && rendition.Layout == "" {
+					rendition.Layout = LayoutPrePaginated
+				}
+			case "orientation-lock":
+				if rendition.Orientation != "" {
+					continue
+				}
+				switch value {
+				case "landscape-only":
+					rendition.Orientation = "landscape"
+				case "portrait-only":
+					rendition.Orientation = "portrait"
+				case "none":
+					rendition.Orientation = "auto"
+				}
+			case "open-to-spread":
+				if value == "true" && rendition.Spread == "" {
+					rendition.Spread = "both"
+				}
+			}
+		}
+	}
+	return rendition, nil
+}
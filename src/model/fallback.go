@@ -0,0 +1,119 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+var ErrNoSupportedFallback = errors.New("no supported item in fallback chain")
+
+// coreMediaTypesV2 are the OPS 2.0.1 core media types.
+var coreMediaTypesV2 = []string{
+	"application/xhtml+xml", "application/x-dtbook+xml", "text/x-oeb1-document",
+	"text/css", "text/x-oeb1-css",
+	"image/gif", "image/jpeg", "image/png", "image/svg+xml",
+	"application/x-dtbncx+xml",
+}
+
+// coreMediaTypesV3 are the EPUB 3.3 core media types.
+var coreMediaTypesV3 = []string{
+	"image/gif", "image/jpeg", "image/png", "image/svg+xml", "image/webp",
+	"audio/mpeg", "audio/mp4", "audio/ogg",
+	"text/css",
+	"font/ttf", "application/font-sfnt", "font/otf", "application/vnd.ms-opentype",
+	"font/woff", "application/font-woff", "font/woff2",
+	"application/xhtml+xml", "application/javascript", "application/ecmascript", "text/javascript",
+	"application/x-dtbncx+xml", "application/smil+xml", "application/pls+xml",
+}
+
+// CoreMediaTypes returns the media types reading systems of the given EPUB
+// version must support. Other resources are foreign resources and need a
+// fallback when they are used in the spine.
+func CoreMediaTypes(version string) []string {
+	if strings.HasPrefix(version, "2") {
+		return append([]string{}, coreMediaTypesV2...)
+	}
+	return append([]string{}, coreMediaTypesV3...)
+}
+
+func (book *Book) IsCoreMediaType(mediaType string) bool {
+	return matchesMediaType(mediaType, CoreMediaTypes(book.Version))
+}
+
+// IsForeignResource reports whether the item is not a core media type. In
+// EPUB 2, XML islands declaring a required-namespace are foreign as well.
+func (book *Book) IsForeignResource(item ManifestItem) bool {
+	return !book.IsCoreMediaType(item.MediaType) || item.RequiredNamespace != ""
+}
+
+func (book *Book) ForeignResources() []ManifestItem {
+	var foreign []ManifestItem
+	if book.Manifest != nil {
+		for _, item := range *book.Manifest {
+			if book.IsForeignResource(item) {
+				foreign = append(foreign, item)
+			}
+		}
+	}
+	return foreign
+}
+
+// FallbackChain returns the item followed by its fallbacks in order. It
+// returns an error if a fallback does not exist or the chain contains a
+// cycle.
+func (book *Book) FallbackChain(item ManifestItem) ([]ManifestItem, error) {
+	chain := []ManifestItem{item}
+	visited := map[string]bool{item.Id: true}
+	for item.Fallback != "" {
+		fallback, ok := book.ManifestItem(item.Fallback)
+		if !ok {
+			return chain, fmt.Errorf("fallback %q of item %q does not exist", item.Fallback, item.Id)
+		}
+		if visited[fallback.Id] {
+			return chain, fmt.Errorf("fallback chain of item %q contains a cycle at %q", chain[0].Id, fallback.Id)
+		}
+		visited[fallback.Id] = true
+		chain = append(chain, fallback)
+		item = fallback
+	}
+	return chain, nil
+}
+
+// ResolveFallback walks the fallback chain of the item and returns the first
+// item with one of the supported media types. Supported media types are
+// compared without parameters and may use wildcards like "image/*".
+func (book *Book) ResolveFallback(item ManifestItem, supported []string) (ManifestItem, error) {
+	chain, err := book.FallbackChain(item)
+	for _, candidate := range chain {
+		if matchesMediaType(candidate.MediaType, supported) {
+			return candidate, nil
+		}
+	}
+	if err != nil {
+		return ManifestItem{}, err
+	}
+	return ManifestItem{}, fmt.Errorf("%w: %s", ErrNoSupportedFallback, item.Id)
+}
+
+func matchesMediaType(mediaType string, supported []string) bool {
+	mediaType = baseMediaType(mediaType)
+	for _, candidate := range supported {
+		candidate = baseMediaType(candidate)
+		if candidate == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(candidate, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func baseMediaType(mediaType string) string {
+	if parsed, _, err := mime.ParseMediaType(mediaType); err == nil {
+		return parsed
+	}
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_resolve_fallback(t *testing.T) {
+	book := &Book{Version: "3.0", Manifest: &[]ManifestItem{
+		{Id: "svg", Href: "c1.svg", MediaType: "image/svg+xml", Fallback: "png"},
+		{Id: "tiff", Href: "c1.tiff", MediaType: "image/tiff", Fallback: "svg"},
+		{Id: "png", Href: "c1.png", MediaType: "image/png"},
+		{Id: "a", Href: "a.xml", MediaType: "application/x-a", Fallback: "b"},
+		{Id: "b", Href: "b.xml", MediaType: "application/x-b", Fallback: "a"},
+		{Id: "broken", Href: "broken.xml", MediaType: "application/x-c", Fallback: "missing"},
+		{Id: "opus", Href: "a.opus", MediaType: "audio/ogg; codecs=opus"},
+	}}
+	tiff, _ := book.ManifestItem("tiff")
+	tests := []struct {
+		supported []string
+		expected  string
+	}{
+		{[]string{"image/png"}, "png"},
+		{[]string{"IMAGE/SVG+XML", "image/png"}, "svg"},
+		{[]string{"image/*"}, "tiff"},
+	}
+	for _, test := range tests {
+		actual, err := book.ResolveFallback(tiff, test.supported)
+		if err != nil || actual.Id != test.expected {
+			t.Logf("ResolveFallback(tiff, %v) expected '%s' but is '%s' (%v)", test.supported, test.expected, actual.Id, err)
+			t.Fail()
+		}
+	}
+	if _, err := book.ResolveFallback(tiff, []string{"image/webp"}); !errors.Is(err, ErrNoSupportedFallback) {
+		t.Logf("expected ErrNoSupportedFallback but is %v", err)
+		t.Fail()
+	}
+
+	a, _ := book.ManifestItem("a")
+	chain, err := book.FallbackChain(a)
+	if err == nil || len(chain) != 2 {
+		t.Logf("expected a cycle error after 2 items but is %d items (%v)", len(chain), err)
+		t.Fail()
+	}
+	if actual, err := book.ResolveFallback(a, []string{"application/x-b"}); err != nil || actual.Id != "b" {
+		t.Logf("expected 'b' before the cycle but is '%s' (%v)", actual.Id, err)
+		t.Fail()
+	}
+	broken, _ := book.ManifestItem("broken")
+	if _, err := book.ResolveFallback(broken, []string{"image/png"}); err == nil || errors.Is(err, ErrNoSupportedFallback) {
+		t.Logf("expected a missing fallback error but is %v", err)
+		t.Fail()
+	}
+}
+
+func Test_foreign_resources(t *testing.T) {
+	manifest := []ManifestItem{
+		{Id: "c1", MediaType: "application/xhtml+xml"},
+		{Id: "webp", MediaType: "image/webp"},
+		{Id: "opus", MediaType: "audio/ogg; codecs=opus"},
+		{Id: "island", MediaType: "application/xhtml+xml", RequiredNamespace: "http://www.w3.org/1998/Math/MathML"},
+		{Id: "pdf", MediaType: "application/pdf"},
+	}
+	tests := []struct {
+		version  string
+		expected []string
+	}{
+		{"3.0", []string{"island", "pdf"}},
+		{"2.0", []string{"webp", "opus", "island", "pdf"}},
+	}
+	for _, test := range tests {
+		book := &Book{Version: test.version, Manifest: &manifest}
+		foreign := book.ForeignResources()
+		ids := make([]string, len(foreign))
+		for i, item := range foreign {
+			ids[i] = item.Id
+		}
+		if len(ids) != len(test.expected) {
+			t.Logf("%s: expected foreign resources %v but is %v", test.version, test.expected, ids)
+			t.Fail()
+			continue
+		}
+		for i := range ids {
+			if ids[i] != test.expected[i] {
+				t.Logf("%s: expected foreign resources %v but is %v", test.version, test.expected, ids)
+				t.Fail()
+				break
+			}
+		}
+	}
+}
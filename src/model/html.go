@@ -0,0 +1,169 @@
+package model
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var namespacePrefixes = map[string]string{
+	"http://www.w3.org/XML/1998/namespace": "xml",
+	"xml":                                  "xml",
+	"http://www.idpf.org/2007/ops":         "epub",
+	"http://www.w3.org/1999/xlink":         "xlink",
+	"http://www.w3.org/2000/svg":           "svg",
+	"http://www.w3.org/1998/Math/MathML":   "math",
+}
+
+// parseDocument parses a content document as XHTML, so that the tree matches
+// the DOM reading systems build and CFIs point to the same nodes. Documents
+// that are not well-formed XML are parsed as HTML instead, which never fails
+// but may add implied elements.
+func parseDocument(reader io.Reader) (*html.Node, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if root, err := parseXHTML(data); err == nil {
+		return root, nil
+	}
+	return html.Parse(bytes.NewReader(data))
+}
+
+func parseXHTML(data []byte) (*html.Node, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Entity = xml.HTMLEntity
+	root := &html.Node{Type: html.DocumentNode}
+	current := root
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			element := &html.Node{
+				Type:      html.ElementNode,
+				Data:      token.Name.Local,
+				DataAtom:  atom.Lookup([]byte(token.Name.Local)),
+				Namespace: elementNamespace(token.Name.Space),
+			}
+			for _, attr := range token.Attr {
+				key := attr.Name.Local
+				if attr.Name.Space == "xmlns" {
+					key = "xmlns:" + key
+				} else if prefix, ok := namespacePrefixes[attr.Name.Space]; ok {
+					key = prefix + ":" + key
+				}
+				element.Attr = append(element.Attr, html.Attribute{Key: key, Val: attr.Value})
+			}
+			current.AppendChild(element)
+			current = element
+		case xml.EndElement:
+			current = current.Parent
+		case xml.CharData:
+			if current == root {
+				continue
+			}
+			if last := current.LastChild; last != nil && last.Type == html.TextNode {
+				last.Data += string(token)
+				continue
+			}
+			current.AppendChild(&html.Node{Type: html.TextNode, Data: string(token)})
+		case xml.Comment:
+			current.AppendChild(&html.Node{Type: html.CommentNode, Data: string(token)})
+		}
+	}
+	if root.FirstChild == nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return root, nil
+}
+
+func elementNamespace(space string) string {
+	switch prefix := namespacePrefixes[space]; prefix {
+	case "svg", "math":
+		return prefix
+	}
+	return ""
+}
+
+// FindElement returns the first element in document order below and
+// including node that matches.
+func FindElement(node *html.Node, match func(node *html.Node) bool) *html.Node {
+	if node.Type == html.ElementNode && match(node) {
+		return node
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if found := FindElement(child, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// FindElements returns all elements below and including node that match.
+func FindElements(node *html.Node, match func(node *html.Node) bool) []*html.Node {
+	var found []*html.Node
+	if node.Type == html.ElementNode && match(node) {
+		found = append(found, node)
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		found = append(found, FindElements(child, match)...)
+	}
+	return found
+}
+
+// Attribute returns the value of an attribute, with its namespace prefix
+// like "epub:type", or an empty string.
+func Attribute(node *html.Node, key string) string {
+	value, _ := AttributeValue(node, key)
+	return value
+}
+
+// AttributeValue returns the value of an attribute and whether it is set.
+func AttributeValue(node *html.Node, key string) (string, bool) {
+	for _, attr := range node.Attr {
+		name := attr.Key
+		if attr.Namespace != "" {
+			name = attr.Namespace + ":" + attr.Key
+		}
+		if name == key {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// HasToken reports whether a space separated attribute value contains token.
+func HasToken(value string, token string) bool {
+	for _, field := range strings.Fields(value) {
+		if field == token {
+			return true
+		}
+	}
+	return false
+}
+
+// textContent returns the text of a node with whitespace collapsed.
+func textContent(node *html.Node) string {
+	builder := strings.Builder{}
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			builder.WriteString(node.Data)
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
@@ -0,0 +1,130 @@
+package model
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+type LandmarkSource string
+
+const (
+	LandmarkSourceNav   LandmarkSource = "nav"
+	LandmarkSourceGuide LandmarkSource = "guide"
+)
+
+// Landmark is an entry of the EPUB 3 landmarks nav or the EPUB 2 guide. Type
+// uses the EPUB 3 structural semantics vocabulary ("cover", "titlepage",
+// "toc", "bodymatter", ...), RawType the value found in the book.
+type Landmark struct {
+	Type     string
+	RawType  string
+	Title    string
+	Href     string
+	Fragment string
+	Source   LandmarkSource
+}
+
+var guideTypes = map[string]string{
+	"acknowledgements": "acknowledgments",
+	"bibliography":     "bibliography",
+	"colophon":         "colophon",
+	"copyright-page":   "copyright-page",
+	"cover":            "cover",
+	"dedication":       "dedication",
+	"epigraph":         "epigraph",
+	"foreword":         "foreword",
+	"glossary":         "glossary",
+	"index":            "index",
+	"loi":              "loi",
+	"lot":              "lot",
+	"notes":            "endnotes",
+	"preface":          "preface",
+	"start":            "bodymatter",
+	"text":             "bodymatter",
+	"title-page":       "titlepage",
+	"toc":              "toc",
+}
+
+// NewGuideLandmark converts a reference of the EPUB 2 guide. The href is
+// relative to the package document.
+func NewGuideLandmark(referenceType string, title string, href string) Landmark {
+	landmark := Landmark{
+		Type:    strings.ToLower(strings.TrimSpace(referenceType)),
+		RawType: referenceType,
+		Title:   strings.TrimSpace(title),
+		Source:  LandmarkSourceGuide,
+	}
+	if mapped, ok := guideTypes[landmark.Type]; ok {
+		landmark.Type = mapped
+	} else {
+		landmark.Type = strings.TrimPrefix(landmark.Type, "other.")
+	}
+	_, landmark.Fragment, _ = strings.Cut(href, "#")
+	landmark.Href = ResolveHref("", href)
+	return landmark
+}
+
+// ReadNavLandmarks reads the landmarks nav of the EPUB 3 navigation
+// document. Books without a navigation document return no landmarks.
+func (book *Book) ReadNavLandmarks() ([]Landmark, error) {
+	nav, ok := book.ManifestItemByProperty("nav")
+	if !ok || !book.exists(book.ItemPath(nav)) {
+		return nil, nil
+	}
+	root, err := book.ReadItemHTML(nav)
+	if err != nil {
+		return nil, err
+	}
+	element := FindElement(root, func(node *html.Node) bool {
+		return node.Data == "nav" && HasToken(Attribute(node, "epub:type"), "landmarks")
+	})
+	if element == nil {
+		return nil, nil
+	}
+	var landmarks []Landmark
+	for _, link := range FindElements(element, func(node *html.Node) bool { return node.Data == "a" }) {
+		rawType := Attribute(link, "epub:type")
+		href := Attribute(link, "href")
+		if rawType == "" || href == "" {
+			continue
+		}
+		landmark := Landmark{
+			Type:    strings.Fields(rawType)[0],
+			RawType: rawType,
+			Title:   textContent(link),
+			Href:    ResolveHref(nav.Href, href),
+			Source:  LandmarkSourceNav,
+		}
+		_, landmark.Fragment, _ = strings.Cut(href, "#")
+		landmarks = append(landmarks, landmark)
+	}
+	return landmarks, nil
+}
+
+// MergeLandmarks returns the nav landmarks followed by the guide references
+// whose type is not already covered by the nav.
+func MergeLandmarks(nav []Landmark, guide []Landmark) []Landmark {
+	landmarks := append([]Landmark{}, nav...)
+	types := make(map[string]bool, len(nav))
+	for _, landmark := range nav {
+		types[landmark.Type] = true
+	}
+	for _, landmark := range guide {
+		if !types[landmark.Type] {
+			landmarks = append(landmarks, landmark)
+		}
+	}
+	return landmarks
+}
+
+// Landmark returns the first landmark of the given type, e.g. "cover" or
+// "bodymatter".
+func (book *Book) Landmark(landmarkType string) (Landmark, bool) {
+	for _, landmark := range book.Landmarks {
+		if landmark.Type == landmarkType {
+			return landmark, true
+		}
+	}
+	return Landmark{}, false
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+type Language struct {
+	Raw   string
+	Tag   language.Tag
+	Valid bool
+}
+
+// ParseLanguage parses a dc:language or xml:lang value as a BCP 47 tag.
+// Case, underscores and ISO 639-2 three-letter codes are normalized, so
+// "en-us", "EN_US" and "eng"-based tags compare equal to their canonical form.
+func ParseLanguage(raw string) Language {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return Language{}
+	}
+	tag, err := language.Parse(raw)
+	if err != nil {
+		return Language{Raw: raw, Tag: language.Und}
+	}
+	return Language{Raw: raw, Tag: tag, Valid: true}
+}
+
+// String returns the canonical tag or the raw value if it could not be parsed.
+func (lang Language) String() string {
+	if !lang.Valid {
+		return lang.Raw
+	}
+	return lang.Tag.String()
+}
+
+func (lang Language) IsEmpty() bool {
+	return lang.Raw == ""
+}
+
+// Base returns the primary language subtag, e.g. "en" for "en-US". Values
+// that are not valid tags are cut at the first "-" or "_".
+func (lang Language) Base() string {
+	if !lang.Valid {
+		base := strings.ToLower(lang.Raw)
+		if i := strings.IndexAny(base, "-_"); i >= 0 {
+			base = base[:i]
+		}
+		return base
+	}
+	base, _ := lang.Tag.Base()
+	return base.String()
+}
+
+func (lang Language) Equal(other Language) bool {
+	return lang.String() == other.String()
+}
+
+// DisplayName returns the name of the language in the language given by
+// displayLanguage, falling back to English. An empty displayLanguage returns
+// the name of the language in itself, e.g. "Deutsch".
+func (lang Language) DisplayName(displayLanguage string) string {
+	if !lang.Valid {
+		return lang.Raw
+	}
+	if displayLanguage == "" {
+		if name := display.Self.Name(lang.Tag); name != "" {
+			return name
+		}
+		return display.English.Tags().Name(lang.Tag)
+	}
+	namer := display.Tags(language.Make(displayLanguage))
+	if namer == nil {
+		namer = display.English.Tags()
+	}
+	return namer.Name(lang.Tag)
+}
+
+// Or returns lang, or fallback if lang is empty. It is used to inherit the
+// xml:lang of an enclosing element.
+func (lang Language) Or(fallback Language) Language {
+	if lang.IsEmpty() {
+		return fallback
+	}
+	return lang
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+type ManifestItem struct {
+	Id                string
+	Href              string
+	MediaType         string
+	Fallback          string
+	FallbackStyle     string
+	RequiredNamespace string
+	RequiredModules   string
+	MediaOverlay      string
+	Properties        []string
+}
+
+type SpineItem struct {
+	Id         string
+	IdRef      string
+	Linear     bool
+	Properties []string
+	Rendition  Rendition
+}
+
+func (item ManifestItem) HasProperty(property string) bool {
+	return hasProperty(item.Properties, property)
+}
+
+func (item ManifestItem) IsXHTML() bool {
+	return item.MediaType == "application/xhtml+xml" || item.MediaType == "text/html"
+}
+
+func (item ManifestItem) IsImage() bool {
+	return strings.HasPrefix(item.MediaType, "image/")
+}
+
+func (item SpineItem) HasProperty(property string) bool {
+	return hasProperty(item.Properties, property)
+}
+
+func hasProperty(properties []string, property string) bool {
+	for _, value := range properties {
+		if value == property {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveHref resolves a reference found in the document at documentHref to
+// an href relative to the package document, as used in the manifest. The
+// fragment is removed and percent-encoding is decoded. Absolute URLs are
+// returned unchanged.
+func ResolveHref(documentHref string, href string) string {
+	href, _, _ = strings.Cut(href, "#")
+	if href == "" || strings.Contains(href, "://") || strings.HasPrefix(href, "data:") {
+		return href
+	}
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		href = unescaped
+	}
+	return path.Clean(path.Join(path.Dir(documentHref), href))
+}
+
+// ItemByHref returns the manifest item with the given href relative to the
+// package document.
+func (book *Book) ItemByHref(href string) (ManifestItem, bool) {
+	if book.Manifest != nil {
+		for _, item := range *book.Manifest {
+			if ResolveHref("", item.Href) == href {
+				return item, true
+			}
+		}
+	}
+	return ManifestItem{}, false
+}
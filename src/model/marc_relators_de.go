@@ -0,0 +1,307 @@
+package model
+
+var relatorLabelsDe = map[string]string{
+	"abr": "Kürzer",
+	"acp": "Kunstkopist",
+	"act": "Schauspieler",
+	"adi": "Art Director",
+	"adp": "Bearbeiter",
+	"aft": "Verfasser eines Nachworts, Kolophons usw.",
+	"anc": "Ansager",
+	"anl": "Analyst",
+	"anm": "Animator",
+	"ann": "Kommentator (Anmerkungen)",
+	"ant": "Bibliographischer Vorgänger",
+	"ape": "Berufungsbeklagter",
+	"apl": "Berufungskläger",
+	"app": "Antragsteller",
+	"aqt": "Autor in Zitaten oder Textauszügen",
+	"arc": "Architekt",
+	"ard": "Künstlerischer Leiter",
+	"arr": "Arrangeur",
+	"art": "Künstler",
+	"asg": "Rechtsnachfolger",
+	"asn": "Assoziierter Name",
+	"ato": "Autograph-Schreiber",
+	"att": "Zugeschriebener Name",
+	"auc": "Auktionator",
+	"aud": "Verfasser von Dialogen",
+	"aue": "Toningenieur (Audio)",
+	"aui": "Verfasser einer Einleitung usw.",
+	"aup": "Audioproduzent",
+	"aus": "Drehbuchautor",
+	"aut": "Autor",
+	"bdd": "Einbandgestalter",
+	"bjd": "Schutzumschlaggestalter",
+	"bka": "Buchkünstler",
+	"bkd": "Buchgestalter",
+	"bkp": "Buchhersteller",
+	"blw": "Klappentextverfasser",
+	"bnd": "Buchbinder",
+	"bpd": "Exlibris-Gestalter",
+	"brd": "Sender",
+	"brl": "Brailledrucker",
+	"bsl": "Buchhändler",
+	"cad": "Besetzungsleiter",
+	"cas": "Gießer",
+	"ccp": "Konzeptersteller",
+	"chr": "Choreograf",
+	"clb": "Mitarbeiter",
+	"cli": "Auftraggeber",
+	"cll": "Kalligraf",
+	"clr": "Kolorist",
+	"clt": "Lichtdrucker",
+	"cmm": "Kommentator",
+	"cmp": "Komponist",
+	"cmt": "Schriftsetzer",
+	"cnd": "Dirigent",
+	"cng": "Kameramann",
+	"cns": "Zensor",
+	"coe": "Anfechtungsberufungsbeklagter",
+	"col": "Sammler",
+	"com": "Kompilator",
+	"con": "Konservator",
+	"cop": "Kameraführung",
+	"cor": "Registrar der Sammlung",
+	"cos": "Anfechtender",
+	"cot": "Anfechtungsberufungskläger",
+	"cou": "Zuständiges Gericht",
+	"cov": "Umschlaggestalter",
+	"cpc": "Urheberrechtsanspruchsteller",
+	"cpe": "Beschwerdeführer-Berufungsbeklagter",
+	"cph": "Urheberrechtsinhaber",
+	"cpl": "Beschwerdeführer",
+	"cpt": "Beschwerdeführer-Berufungskläger",
+	"cre": "Urheber",
+	"crp": "Korrespondent",
+	"crr": "Korrektor",
+	"crt": "Gerichtsreporter",
+	"csl": "Berater",
+	"csp": "Projektberater",
+	"cst": "Kostümbildner",
+	"ctb": "Mitwirkender",
+	"cte": "Angefochtener-Berufungsbeklagter",
+	"ctg": "Kartograf",
+	"ctr": "Auftragnehmer",
+	"cts": "Angefochtener",
+	"ctt": "Angefochtener-Berufungskläger",
+	"cur": "Kurator",
+	"cwt": "Kommentator eines geschriebenen Textes",
+	"dbd": "Synchronregisseur",
+	"dbp": "Vertriebsort",
+	"dfd": "Beklagter",
+	"dfe": "Beklagter-Berufungsbeklagter",
+	"dft": "Beklagter-Berufungskläger",
+	"dgc": "Mitglied der Promotionskommission",
+	"dgg": "Grad verleihende Institution",
+	"dgs": "Doktorvater",
+	"dis": "Doktorand",
+	"djo": "DJ",
+	"dln": "Zeichner (Entwurf)",
+	"dnc": "Tänzer",
+	"dnr": "Spender",
+	"dpc": "Dargestellt",
+	"dpt": "Hinterleger",
+	"drm": "Technischer Zeichner",
+	"drt": "Regisseur",
+	"dsr": "Designer",
+	"dst": "Vertrieb",
+	"dtc": "Datenlieferant",
+	"dte": "Widmungsempfänger",
+	"dtm": "Datenmanager",
+	"dto": "Widmender",
+	"dub": "Zweifelhafter Autor",
+	"edc": "Herausgeber einer Zusammenstellung",
+	"edd": "Redaktionsleiter",
+	"edm": "Cutter eines Bewegtbildwerks",
+	"edt": "Herausgeber",
+	"egr": "Stecher",
+	"elg": "Beleuchter",
+	"elt": "Galvanoplastiker",
+	"eng": "Ingenieur",
+	"enj": "Erlassende Gebietskörperschaft",
+	"etr": "Radierer",
+	"evp": "Veranstaltungsort",
+	"exp": "Experte",
+	"fac": "Faksimilist",
+	"fds": "Filmverleih",
+	"fld": "Feldforschungsleiter",
+	"flm": "Filmeditor",
+	"fmd": "Filmregisseur",
+	"fmk": "Filmemacher",
+	"fmo": "Früherer Besitzer",
+	"fmp": "Filmproduzent",
+	"fnd": "Geldgeber",
+	"fon": "Gründer",
+	"fpy": "Erste Partei",
+	"frg": "Fälscher",
+	"gdv": "Spieleentwickler",
+	"gis": "Geoinformationsspezialist",
+	"grt": "Grafiktechniker",
+	"his": "Gastinstitution",
+	"hnr": "Geehrter",
+	"hst": "Gastgeber",
+	"ill": "Illustrator",
+	"ilu": "Illuminator",
+	"ins": "Inskribent",
+	"inv": "Erfinder",
+	"isb": "Herausgebendes Organ",
+	"itr": "Instrumentalist",
+	"ive": "Interviewter",
+	"ivr": "Interviewer",
+	"jud": "Richter",
+	"jug": "Zuständiger Gerichtsbezirk",
+	"lbr": "Labor",
+	"lbt": "Librettist",
+	"ldr": "Laborleiter",
+	"led": "Leitung",
+	"lee": "Verleumdeter-Berufungsbeklagter",
+	"lel": "Verleumdeter",
+	"len": "Leihgeber",
+	"let": "Verleumdeter-Berufungskläger",
+	"lgd": "Lichtdesigner",
+	"lie": "Verleumdungskläger-Berufungsbeklagter",
+	"lil": "Verleumdungskläger",
+	"lit": "Verleumdungskläger-Berufungskläger",
+	"lsa": "Landschaftsarchitekt",
+	"lse": "Lizenznehmer",
+	"lso": "Lizenzgeber",
+	"ltg": "Lithograf",
+	"ltr": "Letterer",
+	"lyr": "Liedtexter",
+	"mcp": "Notenkopist",
+	"mdc": "Metadatenkontakt",
+	"med": "Medium",
+	"mfp": "Herstellungsort",
+	"mfr": "Hersteller",
+	"mka": "Maskenbildner",
+	"mod": "Moderator",
+	"mon": "Überwacher",
+	"mrb": "Marmorierer",
+	"mrk": "Auszeichnungsredakteur",
+	"msd": "Musikalischer Leiter",
+	"mte": "Metallstecher",
+	"mtk": "Protokollführer",
+	"mup": "Musikprogrammierer",
+	"mus": "Musiker",
+	"mxe": "Mischtoningenieur",
+	"nan": "Nachrichtensprecher",
+	"nrt": "Erzähler",
+	"onp": "Mitwirkender vor der Kamera",
+	"opn": "Opponent",
+	"org": "Urheber (Ursprung)",
+	"orm": "Veranstalter",
+	"osp": "Moderator vor der Kamera",
+	"oth": "Sonstige",
+	"own": "Besitzer",
+	"pad": "Adressort",
+	"pan": "Podiumsteilnehmer",
+	"pat": "Schirmherr",
+	"pbd": "Verlagsleiter",
+	"pbl": "Verlag",
+	"pdr": "Projektleiter",
+	"pfr": "Korrekturleser",
+	"pht": "Fotograf",
+	"plt": "Druckplattenhersteller",
+	"pma": "Genehmigungsbehörde",
+	"pmn": "Produktionsleiter",
+	"pop": "Plattendrucker",
+	"ppm": "Papiermacher",
+	"ppt": "Puppenspieler",
+	"pra": "Präses",
+	"prc": "Prozesskontakt",
+	"prd": "Produktionspersonal",
+	"pre": "Präsentator",
+	"prf": "Darsteller",
+	"prg": "Programmierer",
+	"prm": "Druckgrafiker",
+	"prn": "Produktionsfirma",
+	"pro": "Produzent",
+	"prp": "Produktionsort",
+	"prs": "Szenenbildner",
+	"prt": "Drucker",
+	"prv": "Anbieter",
+	"pta": "Patentanmelder",
+	"pte": "Kläger-Berufungsbeklagter",
+	"ptf": "Kläger",
+	"pth": "Patentinhaber",
+	"ptt": "Kläger-Berufungskläger",
+	"pup": "Erscheinungsort",
+	"rap": "Berichterstatter",
+	"rbr": "Rubrikator",
+	"rcd": "Tonaufnahmeleiter",
+	"rce": "Aufnahmetechniker",
+	"rcp": "Adressat",
+	"rdd": "Hörfunkregisseur",
+	"red": "Redakteur",
+	"ren": "Renderer",
+	"res": "Forscher",
+	"rev": "Rezensent",
+	"rpc": "Hörfunkproduzent",
+	"rps": "Aufbewahrungsort",
+	"rpt": "Reporter",
+	"rpy": "Verantwortliche Partei",
+	"rse": "Antragsgegner-Berufungsbeklagter",
+	"rsg": "Neuinszenierer",
+	"rsp": "Antragsgegner",
+	"rsr": "Restaurator",
+	"rst": "Antragsgegner-Berufungskläger",
+	"rth": "Leiter des Forschungsteams",
+	"rtm": "Mitglied des Forschungsteams",
+	"rxa": "Remix-Künstler",
+	"sad": "Wissenschaftlicher Berater",
+	"sce": "Szenarist",
+	"scl": "Bildhauer",
+	"scr": "Schreiber",
+	"sde": "Tontechniker",
+	"sds": "Sounddesigner",
+	"sec": "Sekretär",
+	"sfx": "Spezialeffekte",
+	"sgd": "Bühnenregisseur",
+	"sgn": "Unterzeichner",
+	"sht": "Co-Moderator",
+	"sll": "Verkäufer",
+	"sng": "Sänger",
+	"spk": "Sprecher",
+	"spn": "Sponsor",
+	"spy": "Zweite Partei",
+	"srv": "Vermesser",
+	"std": "Bühnenbildner",
+	"stg": "Schauplatz",
+	"stl": "Geschichtenerzähler",
+	"stm": "Inspizient",
+	"stn": "Normungsorganisation",
+	"str": "Stereotypeur",
+	"swd": "Softwareentwickler",
+	"tad": "Technischer Berater",
+	"tau": "Fernsehautor",
+	"tcd": "Technischer Leiter",
+	"tch": "Lehrer",
+	"ths": "Betreuer der Abschlussarbeit",
+	"tld": "Fernsehregisseur",
+	"tlg": "Fernsehgast",
+	"tlh": "Fernsehmoderator",
+	"tlp": "Fernsehproduzent",
+	"trc": "Transkribierer",
+	"trl": "Übersetzer",
+	"tyd": "Schriftgestalter",
+	"tyg": "Typograf",
+	"uvp": "Hochschulort",
+	"vac": "Synchronsprecher",
+	"vdg": "Videograf",
+	"vfx": "Visuelle Effekte",
+	"voc": "Vokalist",
+	"wac": "Verfasser eines hinzugefügten Kommentars",
+	"wal": "Verfasser hinzugefügter Liedtexte",
+	"wam": "Verfasser von Begleitmaterial",
+	"wat": "Verfasser von hinzugefügtem Text",
+	"wdc": "Holzschneider",
+	"wde": "Holzstecher",
+	"wfs": "Verfasser der Filmgeschichte",
+	"wft": "Verfasser der Zwischentitel",
+	"win": "Verfasser der Einführung",
+	"wit": "Zeuge",
+	"wpr": "Verfasser des Vorworts",
+	"wst": "Verfasser von ergänzendem Textinhalt",
+	"wts": "Verfasser der Fernsehgeschichte",
+}
@@ -0,0 +1,307 @@
+package model
+
+var relatorLabelsEs = map[string]string{
+	"abr": "abreviador",
+	"acp": "copista de arte",
+	"act": "actor",
+	"adi": "director de arte",
+	"adp": "adaptador",
+	"aft": "autor del epílogo, colofón, etc.",
+	"anc": "locutor",
+	"anl": "analista",
+	"anm": "animador",
+	"ann": "anotador",
+	"ant": "antecedente bibliográfico",
+	"ape": "apelado",
+	"apl": "apelante",
+	"app": "solicitante",
+	"aqt": "autor de citas o resúmenes",
+	"arc": "arquitecto",
+	"ard": "director artístico",
+	"arr": "arreglista",
+	"art": "artista",
+	"asg": "cesionario",
+	"asn": "nombre asociado",
+	"ato": "autógrafo",
+	"att": "nombre atribuido",
+	"auc": "subastador",
+	"aud": "autor del diálogo",
+	"aue": "ingeniero de audio",
+	"aui": "autor de la introducción, etc.",
+	"aup": "productor de audio",
+	"aus": "guionista",
+	"aut": "autor",
+	"bdd": "diseñador de la encuadernación",
+	"bjd": "diseñador de la sobrecubierta",
+	"bka": "artista del libro",
+	"bkd": "diseñador del libro",
+	"bkp": "productor del libro",
+	"blw": "redactor de la contracubierta",
+	"bnd": "encuadernador",
+	"bpd": "diseñador de ex libris",
+	"brd": "emisora",
+	"brl": "impresor braille",
+	"bsl": "librero",
+	"cad": "director de casting",
+	"cas": "fundidor",
+	"ccp": "conceptualizador",
+	"chr": "coreógrafo",
+	"clb": "colaborador",
+	"cli": "cliente",
+	"cll": "calígrafo",
+	"clr": "colorista",
+	"clt": "fototipista",
+	"cmm": "comentarista",
+	"cmp": "compositor",
+	"cmt": "cajista",
+	"cnd": "director de orquesta",
+	"cng": "director de fotografía",
+	"cns": "censor",
+	"coe": "impugnante apelado",
+	"col": "coleccionista",
+	"com": "compilador",
+	"con": "conservador",
+	"cop": "operador de cámara",
+	"cor": "registrador de la colección",
+	"cos": "impugnante",
+	"cot": "impugnante apelante",
+	"cou": "tribunal competente",
+	"cov": "diseñador de la cubierta",
+	"cpc": "reclamante de derechos de autor",
+	"cpe": "querellante apelado",
+	"cph": "titular de los derechos de autor",
+	"cpl": "querellante",
+	"cpt": "querellante apelante",
+	"cre": "creador",
+	"crp": "corresponsal",
+	"crr": "corrector",
+	"crt": "taquígrafo judicial",
+	"csl": "consultor",
+	"csp": "consultor del proyecto",
+	"cst": "diseñador de vestuario",
+	"ctb": "colaborador (contribuidor)",
+	"cte": "impugnado apelado",
+	"ctg": "cartógrafo",
+	"ctr": "contratista",
+	"cts": "impugnado",
+	"ctt": "impugnado apelante",
+	"cur": "comisario",
+	"cwt": "comentarista de texto escrito",
+	"dbd": "director de doblaje",
+	"dbp": "lugar de distribución",
+	"dfd": "demandado",
+	"dfe": "demandado apelado",
+	"dft": "demandado apelante",
+	"dgc": "miembro del tribunal de tesis",
+	"dgg": "institución que otorga el título",
+	"dgs": "director de tesis",
+	"dis": "doctorando",
+	"djo": "DJ",
+	"dln": "delineante",
+	"dnc": "bailarín",
+	"dnr": "donante",
+	"dpc": "representado",
+	"dpt": "depositante",
+	"drm": "dibujante técnico",
+	"drt": "director",
+	"dsr": "diseñador",
+	"dst": "distribuidor",
+	"dtc": "proveedor de datos",
+	"dte": "dedicatario",
+	"dtm": "gestor de datos",
+	"dto": "dedicante",
+	"dub": "autor dudoso",
+	"edc": "editor de la compilación",
+	"edd": "director editorial",
+	"edm": "montador de obra audiovisual",
+	"edt": "editor literario",
+	"egr": "grabador",
+	"elg": "electricista",
+	"elt": "galvanotipista",
+	"eng": "ingeniero",
+	"enj": "jurisdicción promulgadora",
+	"etr": "aguafortista",
+	"evp": "lugar del evento",
+	"exp": "experto",
+	"fac": "facsimilista",
+	"fds": "distribuidor de cine",
+	"fld": "director de campo",
+	"flm": "montador de cine",
+	"fmd": "director de cine",
+	"fmk": "cineasta",
+	"fmo": "antiguo propietario",
+	"fmp": "productor de cine",
+	"fnd": "financiador",
+	"fon": "fundador",
+	"fpy": "primera parte",
+	"frg": "falsificador",
+	"gdv": "desarrollador de videojuegos",
+	"gis": "especialista en información geográfica",
+	"grt": "técnico gráfico",
+	"his": "institución anfitriona",
+	"hnr": "homenajeado",
+	"hst": "anfitrión",
+	"ill": "ilustrador",
+	"ilu": "iluminador",
+	"ins": "inscriptor",
+	"inv": "inventor",
+	"isb": "organismo emisor",
+	"itr": "instrumentista",
+	"ive": "entrevistado",
+	"ivr": "entrevistador",
+	"jud": "juez",
+	"jug": "jurisdicción regida",
+	"lbr": "laboratorio",
+	"lbt": "libretista",
+	"ldr": "director de laboratorio",
+	"led": "líder",
+	"lee": "difamado apelado",
+	"lel": "difamado",
+	"len": "prestador",
+	"let": "difamado apelante",
+	"lgd": "diseñador de iluminación",
+	"lie": "demandante por difamación apelado",
+	"lil": "demandante por difamación",
+	"lit": "demandante por difamación apelante",
+	"lsa": "arquitecto paisajista",
+	"lse": "licenciatario",
+	"lso": "licenciante",
+	"ltg": "litógrafo",
+	"ltr": "rotulista",
+	"lyr": "letrista",
+	"mcp": "copista de música",
+	"mdc": "contacto de metadatos",
+	"med": "médium",
+	"mfp": "lugar de fabricación",
+	"mfr": "fabricante",
+	"mka": "maquillador",
+	"mod": "moderador",
+	"mon": "supervisor",
+	"mrb": "jaspeador",
+	"mrk": "editor de marcado",
+	"msd": "director musical",
+	"mte": "grabador en metal",
+	"mtk": "secretario de actas",
+	"mup": "programador musical",
+	"mus": "músico",
+	"mxe": "ingeniero de mezcla",
+	"nan": "presentador de noticias",
+	"nrt": "narrador",
+	"onp": "participante en pantalla",
+	"opn": "oponente",
+	"org": "originador",
+	"orm": "organizador",
+	"osp": "presentador en pantalla",
+	"oth": "otro",
+	"own": "propietario",
+	"pad": "lugar de dirección",
+	"pan": "panelista",
+	"pat": "mecenas",
+	"pbd": "director de publicaciones",
+	"pbl": "editorial",
+	"pdr": "director del proyecto",
+	"pfr": "corrector de pruebas",
+	"pht": "fotógrafo",
+	"plt": "fabricante de planchas",
+	"pma": "organismo que otorga el permiso",
+	"pmn": "director de producción",
+	"pop": "impresor de láminas",
+	"ppm": "papelero",
+	"ppt": "titiritero",
+	"pra": "praeses",
+	"prc": "contacto del proceso",
+	"prd": "personal de producción",
+	"pre": "presentador",
+	"prf": "intérprete",
+	"prg": "programador",
+	"prm": "grabador-impresor",
+	"prn": "productora",
+	"pro": "productor",
+	"prp": "lugar de producción",
+	"prs": "diseñador de producción",
+	"prt": "impresor",
+	"prv": "proveedor",
+	"pta": "solicitante de patente",
+	"pte": "demandante apelado",
+	"ptf": "demandante",
+	"pth": "titular de la patente",
+	"ptt": "demandante apelante",
+	"pup": "lugar de publicación",
+	"rap": "relator",
+	"rbr": "rubricador",
+	"rcd": "técnico de grabación de campo",
+	"rce": "ingeniero de grabación",
+	"rcp": "destinatario",
+	"rdd": "director de radio",
+	"red": "redactor",
+	"ren": "renderizador",
+	"res": "investigador",
+	"rev": "crítico",
+	"rpc": "productor de radio",
+	"rps": "repositorio",
+	"rpt": "reportero",
+	"rpy": "parte responsable",
+	"rse": "demandado apelado (recurso)",
+	"rsg": "director de reposición",
+	"rsp": "demandado (recurso)",
+	"rsr": "restaurador",
+	"rst": "demandado apelante (recurso)",
+	"rth": "jefe del equipo de investigación",
+	"rtm": "miembro del equipo de investigación",
+	"rxa": "artista de remezcla",
+	"sad": "asesor científico",
+	"sce": "argumentista",
+	"scl": "escultor",
+	"scr": "escriba",
+	"sde": "ingeniero de sonido",
+	"sds": "diseñador de sonido",
+	"sec": "secretario",
+	"sfx": "proveedor de efectos especiales",
+	"sgd": "director de escena",
+	"sgn": "firmante",
+	"sht": "copresentador",
+	"sll": "vendedor",
+	"sng": "cantante",
+	"spk": "orador",
+	"spn": "patrocinador",
+	"spy": "segunda parte",
+	"srv": "agrimensor",
+	"std": "escenógrafo",
+	"stg": "ambientación",
+	"stl": "cuentacuentos",
+	"stm": "regidor de escena",
+	"stn": "organismo de normalización",
+	"str": "estereotipista",
+	"swd": "desarrollador de software",
+	"tad": "asesor técnico",
+	"tau": "guionista de televisión",
+	"tcd": "director técnico",
+	"tch": "profesor",
+	"ths": "director de trabajo de fin de estudios",
+	"tld": "director de televisión",
+	"tlg": "invitado de televisión",
+	"tlh": "presentador de televisión",
+	"tlp": "productor de televisión",
+	"trc": "transcriptor",
+	"trl": "traductor",
+	"tyd": "diseñador tipográfico",
+	"tyg": "tipógrafo",
+	"uvp": "lugar de la universidad",
+	"vac": "actor de doblaje",
+	"vdg": "videógrafo",
+	"vfx": "proveedor de efectos visuales",
+	"voc": "vocalista",
+	"wac": "autor del comentario añadido",
+	"wal": "autor de la letra añadida",
+	"wam": "autor del material complementario",
+	"wat": "autor del texto añadido",
+	"wdc": "xilógrafo (tabla)",
+	"wde": "grabador en madera",
+	"wfs": "autor del argumento cinematográfico",
+	"wft": "autor de los intertítulos",
+	"win": "autor de la introducción",
+	"wit": "testigo",
+	"wpr": "autor del prefacio",
+	"wst": "autor de contenido textual complementario",
+	"wts": "autor del argumento televisivo",
+}
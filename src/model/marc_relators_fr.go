@@ -0,0 +1,307 @@
+package model
+
+var relatorLabelsFr = map[string]string{
+	"abr": "abréviateur",
+	"acp": "copiste d'art",
+	"act": "acteur",
+	"adi": "directeur artistique (cinéma)",
+	"adp": "adaptateur",
+	"aft": "auteur de la postface, du colophon, etc.",
+	"anc": "annonceur",
+	"anl": "analyste",
+	"anm": "animateur (cinéma d'animation)",
+	"ann": "annotateur",
+	"ant": "antécédent bibliographique",
+	"ape": "intimé",
+	"apl": "appelant",
+	"app": "requérant",
+	"aqt": "auteur de citations",
+	"arc": "architecte",
+	"ard": "directeur artistique",
+	"arr": "arrangeur",
+	"art": "artiste",
+	"asg": "cessionnaire",
+	"asn": "nom associé",
+	"ato": "autographe",
+	"att": "nom attribué",
+	"auc": "commissaire-priseur",
+	"aud": "auteur du dialogue",
+	"aue": "ingénieur audio",
+	"aui": "auteur de l'introduction, etc.",
+	"aup": "producteur audio",
+	"aus": "scénariste",
+	"aut": "auteur",
+	"bdd": "concepteur de la reliure",
+	"bjd": "concepteur de la jaquette",
+	"bka": "artiste du livre",
+	"bkd": "concepteur du livre",
+	"bkp": "producteur du livre",
+	"blw": "rédacteur de la quatrième de couverture",
+	"bnd": "relieur",
+	"bpd": "concepteur d'ex-libris",
+	"brd": "diffuseur",
+	"brl": "embosseur braille",
+	"bsl": "libraire",
+	"cad": "directeur de casting",
+	"cas": "fondeur",
+	"ccp": "concepteur",
+	"chr": "chorégraphe",
+	"clb": "collaborateur",
+	"cli": "client",
+	"cll": "calligraphe",
+	"clr": "coloriste",
+	"clt": "phototypeur",
+	"cmm": "commentateur",
+	"cmp": "compositeur",
+	"cmt": "compositeur typographe",
+	"cnd": "chef d'orchestre",
+	"cng": "directeur de la photographie",
+	"cns": "censeur",
+	"coe": "intimé dans une contestation",
+	"col": "collectionneur",
+	"com": "compilateur",
+	"con": "conservateur-restaurateur",
+	"cop": "cadreur",
+	"cor": "régisseur de collection",
+	"cos": "contestataire",
+	"cot": "appelant dans une contestation",
+	"cou": "tribunal compétent",
+	"cov": "concepteur de la couverture",
+	"cpc": "revendicateur du droit d'auteur",
+	"cpe": "plaignant intimé",
+	"cph": "titulaire du droit d'auteur",
+	"cpl": "plaignant",
+	"cpt": "plaignant appelant",
+	"cre": "créateur",
+	"crp": "correspondant",
+	"crr": "correcteur",
+	"crt": "sténographe judiciaire",
+	"csl": "consultant",
+	"csp": "consultant de projet",
+	"cst": "costumier",
+	"ctb": "contributeur",
+	"cte": "contesté intimé",
+	"ctg": "cartographe",
+	"ctr": "contractant",
+	"cts": "contesté",
+	"ctt": "contesté appelant",
+	"cur": "commissaire d'exposition",
+	"cwt": "commentateur de texte écrit",
+	"dbd": "directeur de doublage",
+	"dbp": "lieu de distribution",
+	"dfd": "défendeur",
+	"dfe": "défendeur intimé",
+	"dft": "défendeur appelant",
+	"dgc": "membre du jury de thèse",
+	"dgg": "établissement de soutenance",
+	"dgs": "directeur de thèse",
+	"dis": "auteur de la thèse",
+	"djo": "DJ",
+	"dln": "dessinateur",
+	"dnc": "danseur",
+	"dnr": "donateur",
+	"dpc": "personne représentée",
+	"dpt": "déposant",
+	"drm": "dessinateur technique",
+	"drt": "réalisateur",
+	"dsr": "designer",
+	"dst": "distributeur",
+	"dtc": "fournisseur de données",
+	"dte": "dédicataire",
+	"dtm": "gestionnaire de données",
+	"dto": "dédicateur",
+	"dub": "auteur douteux",
+	"edc": "éditeur de la compilation",
+	"edd": "directeur éditorial",
+	"edm": "monteur d'œuvre audiovisuelle",
+	"edt": "éditeur scientifique",
+	"egr": "graveur",
+	"elg": "électricien",
+	"elt": "galvanotypeur",
+	"eng": "ingénieur",
+	"enj": "juridiction d'adoption",
+	"etr": "aquafortiste",
+	"evp": "lieu de l'événement",
+	"exp": "expert",
+	"fac": "facsimiliste",
+	"fds": "distributeur de films",
+	"fld": "directeur de terrain",
+	"flm": "monteur de film",
+	"fmd": "réalisateur de film",
+	"fmk": "cinéaste",
+	"fmo": "ancien possesseur",
+	"fmp": "producteur de film",
+	"fnd": "bailleur de fonds",
+	"fon": "fondateur",
+	"fpy": "première partie",
+	"frg": "faussaire",
+	"gdv": "développeur de jeux",
+	"gis": "spécialiste en information géographique",
+	"grt": "technicien graphique",
+	"his": "établissement d'accueil",
+	"hnr": "personne honorée",
+	"hst": "hôte",
+	"ill": "illustrateur",
+	"ilu": "enlumineur",
+	"ins": "inscripteur",
+	"inv": "inventeur",
+	"isb": "organisme émetteur",
+	"itr": "instrumentiste",
+	"ive": "interviewé",
+	"ivr": "intervieweur",
+	"jud": "juge",
+	"jug": "juridiction concernée",
+	"lbr": "laboratoire",
+	"lbt": "librettiste",
+	"ldr": "directeur de laboratoire",
+	"led": "meneur",
+	"lee": "diffamé intimé",
+	"lel": "diffamé",
+	"len": "prêteur",
+	"let": "diffamé appelant",
+	"lgd": "éclairagiste",
+	"lie": "plaignant en diffamation intimé",
+	"lil": "plaignant en diffamation",
+	"lit": "plaignant en diffamation appelant",
+	"lsa": "architecte paysagiste",
+	"lse": "licencié",
+	"lso": "concédant de licence",
+	"ltg": "lithographe",
+	"ltr": "lettreur",
+	"lyr": "parolier",
+	"mcp": "copiste de musique",
+	"mdc": "contact pour les métadonnées",
+	"med": "médium",
+	"mfp": "lieu de fabrication",
+	"mfr": "fabricant",
+	"mka": "maquilleur",
+	"mod": "modérateur",
+	"mon": "contrôleur",
+	"mrb": "marbreur",
+	"mrk": "éditeur du balisage",
+	"msd": "directeur musical",
+	"mte": "graveur sur métal",
+	"mtk": "secrétaire de séance",
+	"mup": "programmeur musical",
+	"mus": "musicien",
+	"mxe": "ingénieur du mixage",
+	"nan": "présentateur de journal télévisé",
+	"nrt": "narrateur",
+	"onp": "participant à l'écran",
+	"opn": "opposant",
+	"org": "auteur de l'original",
+	"orm": "organisateur",
+	"osp": "présentateur à l'écran",
+	"oth": "autre",
+	"own": "possesseur",
+	"pad": "lieu d'adresse",
+	"pan": "panéliste",
+	"pat": "mécène",
+	"pbd": "directeur de publication",
+	"pbl": "éditeur commercial",
+	"pdr": "directeur de projet",
+	"pfr": "correcteur d'épreuves",
+	"pht": "photographe",
+	"plt": "clicheur",
+	"pma": "autorité de délivrance",
+	"pmn": "directeur de production",
+	"pop": "imprimeur de planches",
+	"ppm": "papetier",
+	"ppt": "marionnettiste",
+	"pra": "praeses",
+	"prc": "contact pour le processus",
+	"prd": "personnel de production",
+	"pre": "présentateur",
+	"prf": "interprète",
+	"prg": "programmeur",
+	"prm": "graveur-imprimeur",
+	"prn": "société de production",
+	"pro": "producteur",
+	"prp": "lieu de production",
+	"prs": "chef décorateur",
+	"prt": "imprimeur",
+	"prv": "fournisseur",
+	"pta": "déposant de brevet",
+	"pte": "demandeur intimé",
+	"ptf": "demandeur",
+	"pth": "titulaire du brevet",
+	"ptt": "demandeur appelant",
+	"pup": "lieu de publication",
+	"rap": "rapporteur",
+	"rbr": "rubricateur",
+	"rcd": "preneur de son",
+	"rce": "ingénieur d'enregistrement",
+	"rcp": "destinataire",
+	"rdd": "réalisateur radio",
+	"red": "rédacteur",
+	"ren": "rendu",
+	"res": "chercheur",
+	"rev": "critique",
+	"rpc": "producteur radio",
+	"rps": "dépositaire",
+	"rpt": "reporter",
+	"rpy": "partie responsable",
+	"rse": "défendeur intimé (requête)",
+	"rsg": "metteur en scène de reprise",
+	"rsp": "défendeur (requête)",
+	"rsr": "restaurateur",
+	"rst": "défendeur appelant (requête)",
+	"rth": "directeur de l'équipe de recherche",
+	"rtm": "membre de l'équipe de recherche",
+	"rxa": "artiste de remix",
+	"sad": "conseiller scientifique",
+	"sce": "scénariste (scénario)",
+	"scl": "sculpteur",
+	"scr": "scribe",
+	"sde": "ingénieur du son",
+	"sds": "concepteur sonore",
+	"sec": "secrétaire",
+	"sfx": "effets spéciaux",
+	"sgd": "metteur en scène",
+	"sgn": "signataire",
+	"sht": "coanimateur",
+	"sll": "vendeur",
+	"sng": "chanteur",
+	"spk": "orateur",
+	"spn": "parrain",
+	"spy": "deuxième partie",
+	"srv": "arpenteur",
+	"std": "décorateur",
+	"stg": "cadre",
+	"stl": "conteur",
+	"stm": "régisseur",
+	"stn": "organisme de normalisation",
+	"str": "clicheur (stéréotypie)",
+	"swd": "développeur de logiciels",
+	"tad": "conseiller technique",
+	"tau": "scénariste de télévision",
+	"tcd": "directeur technique",
+	"tch": "enseignant",
+	"ths": "directeur de mémoire",
+	"tld": "réalisateur de télévision",
+	"tlg": "invité de télévision",
+	"tlh": "animateur de télévision",
+	"tlp": "producteur de télévision",
+	"trc": "transcripteur",
+	"trl": "traducteur",
+	"tyd": "créateur de caractères",
+	"tyg": "typographe",
+	"uvp": "lieu de l'université",
+	"vac": "acteur de doublage",
+	"vdg": "vidéaste",
+	"vfx": "effets visuels",
+	"voc": "vocaliste",
+	"wac": "auteur du commentaire ajouté",
+	"wal": "auteur des paroles ajoutées",
+	"wam": "auteur du matériel d'accompagnement",
+	"wat": "auteur du texte ajouté",
+	"wdc": "graveur sur bois (taille d'épargne)",
+	"wde": "graveur sur bois",
+	"wfs": "auteur de l'histoire du film",
+	"wft": "auteur des intertitres",
+	"win": "auteur de l'introduction",
+	"wit": "témoin",
+	"wpr": "auteur de la préface",
+	"wst": "auteur de contenu textuel supplémentaire",
+	"wts": "auteur de l'histoire télévisée",
+}
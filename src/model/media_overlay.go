@@ -0,0 +1,196 @@
+package model
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type MediaOverlays struct {
+	Duration            time.Duration
+	ItemDurations       map[string]time.Duration
+	Narrators           []string
+	ActiveClass         string
+	PlaybackActiveClass string
+}
+
+type MediaOverlay struct {
+	Item     ManifestItem
+	Duration time.Duration
+	Pars     []Par
+}
+
+type Par struct {
+	Id           string
+	Type         string
+	TextHref     string
+	TextFragment string
+	AudioHref    string
+	ClipBegin    time.Duration
+	ClipEnd      time.Duration
+}
+
+type smilPar struct {
+	Id    string     `xml:"id,attr"`
+	Type  string     `xml:"type,attr"`
+	Text  smilMedia  `xml:"text"`
+	Audio *smilAudio `xml:"audio"`
+}
+
+type smilMedia struct {
+	Src string `xml:"src,attr"`
+}
+
+type smilAudio struct {
+	Src       string `xml:"src,attr"`
+	ClipBegin string `xml:"clipBegin,attr"`
+	ClipEnd   string `xml:"clipEnd,attr"`
+}
+
+// Set stores the value of a media:* meta property. refines is the id of the
+// refined manifest item, or empty for package level metadata. Durations that
+// are not valid clock values are ignored.
+func (overlays *MediaOverlays) Set(property string, refines string, value string) {
+	value = strings.TrimSpace(value)
+	switch property {
+	case "media:duration":
+		duration, err := ParseClockValue(value)
+		if err != nil {
+			return
+		}
+		if refines == "" {
+			overlays.Duration = duration
+			return
+		}
+		if overlays.ItemDurations == nil {
+			overlays.ItemDurations = make(map[string]time.Duration)
+		}
+		overlays.ItemDurations[refines] = duration
+	case "media:narrator":
+		overlays.Narrators = append(overlays.Narrators, value)
+	case "media:active-class":
+		overlays.ActiveClass = value
+	case "media:playback-active-class":
+		overlays.PlaybackActiveClass = value
+	}
+}
+
+// MediaOverlayFor returns the SMIL manifest item of a content document.
+func (book *Book) MediaOverlayFor(item ManifestItem) (ManifestItem, bool) {
+	if item.MediaOverlay == "" {
+		return ManifestItem{}, false
+	}
+	return book.ManifestItem(item.MediaOverlay)
+}
+
+// ReadMediaOverlay parses a SMIL media overlay document into the flat list
+// of its par elements in playback order. Text and audio references are
+// resolved relative to the package document, like manifest hrefs.
+func (book *Book) ReadMediaOverlay(item ManifestItem) (*MediaOverlay, error) {
+	reader, err := book.OpenItem(item)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	overlay := &MediaOverlay{Item: item, Duration: book.MediaOverlays.ItemDurations[item.Id]}
+	decoder := xml.NewDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "par" {
+			continue
+		}
+		smil := smilPar{}
+		err = decoder.DecodeElement(&smil, &start)
+		if err != nil {
+			return nil, err
+		}
+		par, err := newPar(item.Href, smil)
+		if err != nil {
+			return nil, err
+		}
+		overlay.Pars = append(overlay.Pars, par)
+	}
+	return overlay, nil
+}
+
+func newPar(smilHref string, smil smilPar) (Par, error) {
+	par := Par{Id: smil.Id, Type: smil.Type}
+	par.TextHref, par.TextFragment, _ = strings.Cut(smil.Text.Src, "#")
+	par.TextHref = ResolveHref(smilHref, par.TextHref)
+	if smil.Audio == nil {
+		return par, nil
+	}
+	par.AudioHref = ResolveHref(smilHref, smil.Audio.Src)
+	var err error
+	if smil.Audio.ClipBegin != "" {
+		par.ClipBegin, err = ParseClockValue(smil.Audio.ClipBegin)
+		if err != nil {
+			return par, err
+		}
+	}
+	if smil.Audio.ClipEnd != "" {
+		par.ClipEnd, err = ParseClockValue(smil.Audio.ClipEnd)
+		if err != nil {
+			return par, err
+		}
+	}
+	return par, nil
+}
+
+var (
+	fullClock    = regexp.MustCompile(`^(\d+):(\d{2}):(\d{2}(?:\.\d+)?)$`)
+	partialClock = regexp.MustCompile(`^(\d{2}):(\d{2}(?:\.\d+)?)$`)
+	timecount    = regexp.MustCompile(`^(\d+(?:\.\d+)?)(h|min|s|ms)?$`)
+)
+
+var timecountUnits = map[string]time.Duration{
+	"h":   time.Hour,
+	"min": time.Minute,
+	"s":   time.Second,
+	"":    time.Second,
+	"ms":  time.Millisecond,
+}
+
+// ParseClockValue parses a SMIL 3.0 clock value: full ("1:02:03.5"),
+// partial ("02:03.5") or a timecount ("3.5s", "200ms", "1.5h", "2min", "12").
+// Minutes and seconds of clock values must be below 60.
+func ParseClockValue(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if match := timecount.FindStringSubmatch(value); match != nil {
+		number, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid clock value %q", value)
+		}
+		return time.Duration(number * float64(timecountUnits[match[2]])), nil
+	}
+	match := fullClock.FindStringSubmatch(value)
+	if match == nil {
+		match = partialClock.FindStringSubmatch(value)
+	}
+	if match == nil {
+		return 0, fmt.Errorf("invalid clock value %q", value)
+	}
+	// The last field is the seconds, the one before the minutes and in a
+	// full clock the first the hours, which are not limited.
+	total := 0.0
+	for i, field := range match[1:] {
+		number, err := strconv.ParseFloat(field, 64)
+		if err != nil || ((len(match) == 3 || i > 0) && number >= 60) {
+			return 0, fmt.Errorf("invalid clock value %q", value)
+		}
+		total = total*60 + number
+	}
+	return time.Duration(total * float64(time.Second)), nil
+}
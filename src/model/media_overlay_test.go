@@ -0,0 +1,36 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_parse_clock_value(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"02:30:03", 2*time.Hour + 30*time.Minute + 3*time.Second},
+		{"50:00:10.25", 50*time.Hour + 10*time.Second + 250*time.Millisecond},
+		{"02:33", 2*time.Minute + 33*time.Second},
+		{"00:10.5", 10*time.Second + 500*time.Millisecond},
+		{"3.2h", 3*time.Hour + 12*time.Minute},
+		{"45min", 45 * time.Minute},
+		{"30s", 30 * time.Second},
+		{"5ms", 5 * time.Millisecond},
+		{"12.467", 12*time.Second + 467*time.Millisecond},
+	}
+	for _, test := range tests {
+		actual, err := ParseClockValue(test.value)
+		if err != nil || actual != test.expected {
+			t.Logf("ParseClockValue(%q) expected '%s' but is '%s' (%v)", test.value, test.expected, actual, err)
+			t.Fail()
+		}
+	}
+	for _, value := range []string{"", "abc", "1:60:00", "00:61", "-3s", "1:2:3:4", "NaN", "Inf", "+5", "1e1", "75:00", "00:75", "1:75:00", "+1:00:00", "1:2:3", "1.5:00"} {
+		if _, err := ParseClockValue(value); err == nil {
+			t.Logf("ParseClockValue(%q) expected an error", value)
+			t.Fail()
+		}
+	}
+}
@@ -1,36 +1,68 @@
 package model
 
 type Metadata struct {
-	MainId       Identifier
-	Titles       *[]Title
-	Identifiers  *[]Identifier
-	Languages    *[]string
-	Creators     *[]Creator
-	Contributors *[]Creator
-	Publishers   *[]DefaultAttributes
-	Subjects     *[]DefaultAttributes
-	Descriptions *[]DefaultAttributes
-	Dates        *[]string
+	MainId        Identifier
+	Titles        *[]Title
+	Identifiers   *[]Identifier
+	Languages     *[]Language
+	Creators      *[]Creator
+	Contributors  *[]Creator
+	Publishers    *[]DefaultAttributes
+	Subjects      *[]DefaultAttributes
+	Descriptions  *[]DefaultAttributes
+	Dates         *[]string
+	Accessibility *Accessibility
+}
+
+// Values returns the elements of an optional slice like the metadata fields,
+// or nil if it is not set.
+func Values[T any](slice *[]T) []T {
+	if slice == nil {
+		return nil
+	}
+	return *slice
 }
 
 type Creator struct {
-	Name     string
-	Language string
-	FileAs   string
-	Role     string
-	RawRole  string
+	Name         string
+	Language     Language
+	Dir          Direction
+	FileAs       string
+	FileAsOrigin Origin
+	Role         string
+	RawRole      string
+	RoleScheme   string
+	RoleStatus   RoleStatus
 }
 
+type RoleStatus string
+
+const (
+	RoleAbsent       RoleStatus = "absent"
+	RoleUnrecognized RoleStatus = "unrecognized"
+	RoleResolved     RoleStatus = "resolved"
+)
+
 type Title struct {
-	Title    string
-	Language string
-	Type     string
-	FileAs   string
+	Title        string
+	Language     Language
+	Dir          Direction
+	Type         string
+	FileAs       string
+	FileAsOrigin Origin
 }
 
+type Origin string
+
+const (
+	OriginBook      Origin = "book"
+	OriginGenerated Origin = "generated"
+)
+
 type DefaultAttributes struct {
 	Text     string
-	Language string
+	Language Language
+	Dir      Direction
 }
 
 type Identifier struct {
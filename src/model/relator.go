@@ -0,0 +1,125 @@
+package model
+
+import (
+	"sort"
+	"strings"
+)
+
+type RelatorTable struct {
+	labels     map[string]map[string]string
+	codes      map[string]map[string]string
+	deprecated map[string]string
+}
+
+type RelatorCode struct {
+	Code       string
+	Deprecated bool
+	UseInstead string
+}
+
+var relatorLanguages = []string{"en", "de", "fr", "es"}
+
+// Relator is the MARC relator list of the Library of Congress
+// (https://id.loc.gov/vocabulary/relators) with English, German, French and
+// Spanish labels. Deprecated codes are kept so that older books still resolve.
+var Relator = newRelatorTable(
+	map[string]map[string]string{
+		"en": relatorLabelsEn,
+		"de": relatorLabelsDe,
+		"fr": relatorLabelsFr,
+		"es": relatorLabelsEs,
+	},
+	map[string]string{
+		"clb": "ctb",
+		"grt": "art",
+		"voc": "sng",
+	},
+)
+
+func newRelatorTable(labels map[string]map[string]string, deprecated map[string]string) RelatorTable {
+	table := RelatorTable{
+		labels:     labels,
+		codes:      make(map[string]map[string]string),
+		deprecated: deprecated,
+	}
+	for language, languageLabels := range labels {
+		codes := make(map[string]string)
+		for _, code := range sortedKeys(languageLabels) {
+			key := strings.ToLower(languageLabels[code])
+			existing, exists := codes[key]
+			if !exists || (table.IsDeprecated(existing) && !table.IsDeprecated(code)) {
+				codes[key] = code
+			}
+		}
+		table.codes[language] = codes
+	}
+	return table
+}
+
+// Label returns the label of a relator code in the given language. Unknown
+// languages fall back to English.
+func (table RelatorTable) Label(code string, language string) (string, bool) {
+	code = normalizeRelatorCode(code)
+	languageLabels, ok := table.labels[ParseLanguage(language).Base()]
+	if !ok {
+		languageLabels = table.labels["en"]
+	}
+	label, ok := languageLabels[code]
+	return label, ok
+}
+
+// Code returns the relator code for a label in any of the supported
+// languages, e.g. "illustrator" or "Illustrator" both return "ill".
+func (table RelatorTable) Code(label string) (string, bool) {
+	label = strings.ToLower(strings.TrimSpace(label))
+	for _, language := range relatorLanguages {
+		if code, ok := table.codes[language][label]; ok {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+func (table RelatorTable) Lookup(code string) (RelatorCode, bool) {
+	code = normalizeRelatorCode(code)
+	if !table.IsValid(code) {
+		return RelatorCode{}, false
+	}
+	useInstead, deprecated := table.deprecated[code]
+	return RelatorCode{
+		Code:       code,
+		Deprecated: deprecated,
+		UseInstead: useInstead,
+	}, true
+}
+
+func (table RelatorTable) IsValid(code string) bool {
+	_, ok := table.labels["en"][normalizeRelatorCode(code)]
+	return ok
+}
+
+func (table RelatorTable) IsDeprecated(code string) bool {
+	_, ok := table.deprecated[normalizeRelatorCode(code)]
+	return ok
+}
+
+func (table RelatorTable) Codes() []string {
+	return sortedKeys(table.labels["en"])
+}
+
+func (table RelatorTable) Languages() []string {
+	return append([]string{}, relatorLanguages...)
+}
+
+func normalizeRelatorCode(code string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(code)), "-")
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,75 @@
+package model
+
+import "testing"
+
+func Test_relator_label(t *testing.T) {
+	tests := []struct {
+		code     string
+		language string
+		expected string
+	}{
+		{"aut", "en", "author"},
+		{"aut", "de-DE", "Autor"},
+		{"ill", "fr", "illustrateur"},
+		{"trl", "es", "traductor"},
+		{"trl", "ja", "translator"},
+		{"-clb", "en", "collaborator"},
+		{"CLB", "en", "collaborator"},
+	}
+	for _, test := range tests {
+		actual, _ := Relator.Label(test.code, test.language)
+		if actual != test.expected {
+			t.Logf("Label(%q, %q) expected '%s' but is '%s'", test.code, test.language, test.expected, actual)
+			t.Fail()
+		}
+	}
+	if _, ok := Relator.Label("xyz", "en"); ok {
+		t.Log("Label of unknown code should not be found")
+		t.Fail()
+	}
+}
+
+func Test_relator_code(t *testing.T) {
+	tests := []struct {
+		label    string
+		expected string
+	}{
+		{"illustrator", "ill"},
+		{"Illustrator", "ill"},
+		{"Übersetzer", "trl"},
+		{"traducteur", "trl"},
+		{"book producer", "bkp"},
+	}
+	for _, test := range tests {
+		actual, _ := Relator.Code(test.label)
+		if actual != test.expected {
+			t.Logf("Code(%q) expected '%s' but is '%s'", test.label, test.expected, actual)
+			t.Fail()
+		}
+	}
+}
+
+func Test_relator_lookup(t *testing.T) {
+	relator, ok := Relator.Lookup("voc")
+	if !ok || !relator.Deprecated || relator.UseInstead != "sng" {
+		t.Logf("voc expected to be deprecated in favour of sng but is %+v", relator)
+		t.Fail()
+	}
+	relator, ok = Relator.Lookup("aut")
+	if !ok || relator.Deprecated {
+		t.Logf("aut expected to be valid but is %+v", relator)
+		t.Fail()
+	}
+	if Relator.IsValid("xyz") {
+		t.Log("xyz expected to be invalid")
+		t.Fail()
+	}
+	for _, language := range Relator.Languages() {
+		for _, code := range Relator.Codes() {
+			if _, ok := Relator.labels[language][code]; !ok {
+				t.Logf("code %s has no %s label", code, language)
+				t.Fail()
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package model
+
+import (
+	"strings"
+)
+
+const (
+	LayoutReflowable   = "reflowable"
+	LayoutPrePaginated = "pre-paginated"
+	PageSpreadLeft     = "left"
+	PageSpreadRight    = "right"
+	PageSpreadCenter   = "center"
+)
+
+type Rendition struct {
+	Layout      string
+	Orientation string
+	Spread      string
+	Flow        string
+	Viewport    string
+	PageSpread  string
+}
+
+var renditionValues = map[string][]string{
+	"layout":      {"reflowable", "pre-paginated"},
+	"orientation": {"auto", "landscape", "portrait"},
+	"spread":      {"auto", "none", "landscape", "portrait", "both"},
+	"flow":        {"auto", "paginated", "scrolled-continuous", "scrolled-doc"},
+}
+
+// Set stores the value of a rendition:* meta property. Unknown properties
+// and values are ignored.
+func (rendition *Rendition) Set(property string, value string) {
+	name, found := strings.CutPrefix(property, "rendition:")
+	if !found {
+		return
+	}
+	value = strings.TrimSpace(value)
+	if name == "viewport" {
+		rendition.Viewport = value
+		return
+	}
+	if !isRenditionValue(name, value) {
+		return
+	}
+	rendition.setField(name, value)
+}
+
+// Override applies the rendition and page spread properties of a spine
+// itemref on top of the package rendition.
+func (rendition Rendition) Override(properties []string) Rendition {
+	for _, property := range properties {
+		switch property {
+		case "page-spread-left", "rendition:page-spread-left":
+			rendition.PageSpread = PageSpreadLeft
+		case "page-spread-right", "rendition:page-spread-right":
+			rendition.PageSpread = PageSpreadRight
+		case "page-spread-center", "rendition:page-spread-center":
+			rendition.PageSpread = PageSpreadCenter
+		default:
+			name, found := strings.CutPrefix(property, "rendition:")
+			if !found {
+				continue
+			}
+			for field := range renditionValues {
+				value, ok := strings.CutPrefix(name, field+"-")
+				if ok && isRenditionValue(field, value) {
+					rendition.setField(field, value)
+				}
+			}
+		}
+	}
+	return rendition
+}
+
+// Or fills the properties that are not set with the ones of fallback.
+func (rendition Rendition) Or(fallback Rendition) Rendition {
+	if rendition.Layout == "" {
+		rendition.Layout = fallback.Layout
+	}
+	if rendition.Orientation == "" {
+		rendition.Orientation = fallback.Orientation
+	}
+	if rendition.Spread == "" {
+		rendition.Spread = fallback.Spread
+	}
+	if rendition.Flow == "" {
+		rendition.Flow = fallback.Flow
+	}
+	if rendition.Viewport == "" {
+		rendition.Viewport = fallback.Viewport
+	}
+	return rendition
+}
+
+// WithDefaults fills the properties that are not set with the defaults of
+// the EPUB 3 specification.
+func (rendition Rendition) WithDefaults() Rendition {
+	return rendition.Or(Rendition{
+		Layout:      LayoutReflowable,
+		Orientation: "auto",
+		Spread:      "auto",
+		Flow:        "auto",
+	})
+}
+
+func (rendition Rendition) IsFixedLayout() bool {
+	return rendition.Layout == LayoutPrePaginated
+}
+
+func (rendition *Rendition) setField(name string, value string) {
+	switch name {
+	case "layout":
+		rendition.Layout = value
+	case "orientation":
+		rendition.Orientation = value
+	case "spread":
+		rendition.Spread = value
+	case "flow":
+		rendition.Flow = value
+	}
+}
+
+func isRenditionValue(name string, value string) bool {
+	for _, allowed := range renditionValues[name] {
+		if allowed == value {
+			return true
+		}
+	}
+	return false
+}
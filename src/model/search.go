@@ -0,0 +1,177 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const defaultSnippetLength = 40
+
+type SearchOptions struct {
+	// CaseSensitive disables case folding.
+	CaseSensitive bool
+	// IgnoreDiacritics matches "cafe" with "café".
+	IgnoreDiacritics bool
+	// WholeWord only matches if the query is not part of a longer word.
+	WholeWord bool
+	// SnippetLength is the number of characters of context on each side of
+	// the match, 40 if zero.
+	SnippetLength int
+}
+
+type SearchResult struct {
+	SpineIndex int
+	Href       string
+	Title      string
+	// Match is the matched text as it appears in the book.
+	Match   string
+	Snippet string
+	// Offset is the byte offset of the match in the chapter text.
+	Offset int
+	CFI    string
+}
+
+// Search finds all occurrences of query in the text of the spine documents.
+func (book *Book) Search(query string, options SearchOptions) ([]SearchResult, error) {
+	chapters, err := book.ExtractText()
+	if err != nil {
+		return nil, err
+	}
+	spineStep, err := book.spineStep()
+	var results []SearchResult
+	for _, chapter := range chapters {
+		results = append(results, book.searchChapter(chapter, query, options, spineStep, err == nil)...)
+	}
+	return results, nil
+}
+
+// SearchChapter finds all occurrences of query in the text of one chapter.
+func (book *Book) SearchChapter(chapter ChapterText, query string, options SearchOptions) []SearchResult {
+	spineStep, err := book.spineStep()
+	return book.searchChapter(chapter, query, options, spineStep, err == nil)
+}
+
+// searchChapter is SearchChapter with the step of the spine element computed
+// once per search. The results get a CFI only if hasStep is set.
+func (book *Book) searchChapter(chapter ChapterText, query string, options SearchOptions, spineStep int, hasStep bool) []SearchResult {
+	needle, _ := normalizeSearchText(strings.Join(strings.Fields(query), " "), options)
+	if needle == "" {
+		return nil
+	}
+	haystack, origins := normalizeSearchText(chapter.Text, options)
+	var results []SearchResult
+	for from := 0; from < len(haystack); {
+		index := strings.Index(haystack[from:], needle)
+		if index < 0 {
+			break
+		}
+		start, end := origins[from+index], origins[from+index+len(needle)]
+		_, size := utf8.DecodeRuneInString(haystack[from+index:])
+		from += index + size
+		if options.WholeWord && !isWordBoundary(chapter.Text, start, end) {
+			continue
+		}
+		result := SearchResult{
+			SpineIndex: chapter.SpineIndex,
+			Href:       chapter.Href,
+			Title:      chapter.Title,
+			Match:      chapter.Text[start:end],
+			Snippet:    snippet(chapter.Text, start, end, options.SnippetLength),
+			Offset:     start,
+		}
+		if hasStep {
+			if location, err := book.textCFI(chapter, start, spineStep); err == nil {
+				result.CFI = location.String()
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// normalizeSearchText folds case and removes diacritics as requested by the
+// options. Whitespace runs are collapsed to a single space so that queries
+// match across line and paragraph breaks. origins maps every byte offset of
+// the normalized text, and its end, to the byte offset in text.
+func normalizeSearchText(text string, options SearchOptions) (string, []int) {
+	builder := strings.Builder{}
+	origins := make([]int, 0, len(text)+1)
+	space := false
+	for offset, r := range text {
+		if unicode.IsSpace(r) {
+			if space {
+				continue
+			}
+			space = true
+			r = ' '
+		} else {
+			space = false
+		}
+		var normalized []rune
+		if options.IgnoreDiacritics {
+			for _, decomposed := range norm.NFD.String(string(r)) {
+				if !unicode.Is(unicode.Mn, decomposed) {
+					normalized = append(normalized, decomposed)
+				}
+			}
+		} else {
+			normalized = []rune{r}
+		}
+		for _, n := range normalized {
+			if !options.CaseSensitive {
+				n = unicode.ToLower(n)
+			}
+			for i := utf8.RuneLen(n); i > 0; i-- {
+				origins = append(origins, offset)
+			}
+			builder.WriteRune(n)
+		}
+	}
+	origins = append(origins, len(text))
+	return builder.String(), origins
+}
+
+func isWordBoundary(text string, start int, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(text[:start])
+	after, _ := utf8.DecodeRuneInString(text[end:])
+	return !isWordRune(before) && !isWordRune(after)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+// snippet returns the match with up to length characters of context on each
+// side, cut at word boundaries where possible and on a single line.
+func snippet(text string, start int, end int, length int) string {
+	if length <= 0 {
+		length = defaultSnippetLength
+	}
+	from := start
+	for count := 0; from > 0 && count < length; count++ {
+		_, size := utf8.DecodeLastRuneInString(text[:from])
+		from -= size
+	}
+	to := end
+	for count := 0; to < len(text) && count < length; count++ {
+		_, size := utf8.DecodeRuneInString(text[to:])
+		to += size
+	}
+	prefix, suffix := "", ""
+	if from > 0 {
+		if space := strings.IndexFunc(text[from:start], unicode.IsSpace); space >= 0 {
+			from += space
+		}
+		prefix = "…"
+	}
+	if to < len(text) {
+		if space := strings.LastIndexFunc(text[end:to], unicode.IsSpace); space >= 0 {
+			to = end + space
+		}
+		suffix = "…"
+	}
+	return prefix + strings.Join(strings.Fields(text[from:to]), " ") + suffix
+}
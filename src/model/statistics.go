@@ -0,0 +1,126 @@
+package model
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+const DefaultWordsPerMinute = 250
+
+// Statistics counts the words, characters (without whitespace), paragraphs
+// and images of a text. Chinese and Japanese characters are counted as one
+// word each, as these scripts do not separate words with spaces.
+type Statistics struct {
+	Words      int
+	Characters int
+	Paragraphs int
+	Images     int
+}
+
+type ChapterStatistics struct {
+	Statistics
+	SpineIndex int
+	Href       string
+	Title      string
+	// Err is set if the document could not be read and is not counted.
+	Err error
+}
+
+type BookStatistics struct {
+	Statistics
+	Chapters []ChapterStatistics
+}
+
+// Statistics returns the statistics of every spine document and their sum.
+func (book *Book) Statistics() (BookStatistics, error) {
+	chapters, err := book.ExtractText()
+	if err != nil {
+		return BookStatistics{}, err
+	}
+	statistics := BookStatistics{}
+	for _, chapter := range chapters {
+		chapterStatistics := ChapterStatistics{
+			Statistics: chapter.Statistics(),
+			SpineIndex: chapter.SpineIndex,
+			Href:       chapter.Href,
+			Title:      chapter.Title,
+			Err:        chapter.Err,
+		}
+		statistics.Chapters = append(statistics.Chapters, chapterStatistics)
+		statistics.Statistics = statistics.Add(chapterStatistics.Statistics)
+	}
+	return statistics, nil
+}
+
+func (chapter ChapterText) Statistics() Statistics {
+	statistics := CountText(chapter.Text)
+	statistics.Images = chapter.Images
+	return statistics
+}
+
+// CountText counts the words, characters and paragraphs of a plain text in
+// which paragraphs are separated by blank lines.
+func CountText(text string) Statistics {
+	statistics := Statistics{}
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		if strings.TrimSpace(paragraph) != "" {
+			statistics.Paragraphs++
+		}
+	}
+	inWord := false
+	runes := []rune(text)
+	for i, r := range runes {
+		if !unicode.IsSpace(r) {
+			statistics.Characters++
+		}
+		switch {
+		case isIdeographic(r):
+			statistics.Words++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			if !inWord {
+				statistics.Words++
+				inWord = true
+			}
+		case inWord && isWordJoiner(runes, i):
+			// "don't", "well-known" and "3.5" are single words.
+		default:
+			inWord = false
+		}
+	}
+	return statistics
+}
+
+func isIdeographic(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Bopomofo)
+}
+
+func isWordJoiner(runes []rune, i int) bool {
+	switch runes[i] {
+	case '\'', '’', '-':
+		return true
+	case '.', ',':
+		// Only within numbers, "end.Next" without a space is two words.
+		return i > 0 && i+1 < len(runes) && unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1])
+	}
+	return false
+}
+
+func (statistics Statistics) Add(other Statistics) Statistics {
+	return Statistics{
+		Words:      statistics.Words + other.Words,
+		Characters: statistics.Characters + other.Characters,
+		Paragraphs: statistics.Paragraphs + other.Paragraphs,
+		Images:     statistics.Images + other.Images,
+	}
+}
+
+// ReadingTime estimates the reading time at the given words per minute,
+// DefaultWordsPerMinute if wordsPerMinute is not positive.
+func (statistics Statistics) ReadingTime(wordsPerMinute int) time.Duration {
+	if wordsPerMinute <= 0 {
+		wordsPerMinute = DefaultWordsPerMinute
+	}
+	return time.Duration(statistics.Words) * time.Minute / time.Duration(wordsPerMinute)
+}
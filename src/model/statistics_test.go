@@ -0,0 +1,44 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_count_text(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected Statistics
+	}{
+		{"", Statistics{}},
+		{"Hello, world!", Statistics{Words: 2, Characters: 12, Paragraphs: 1}},
+		{"Don't stop — it's well-known.\n\nVersion 3.5 works.", Statistics{Words: 7, Characters: 41, Paragraphs: 2}},
+		{"吾輩は猫である。", Statistics{Words: 7, Characters: 8, Paragraphs: 1}},
+		{"Go言語 is fun", Statistics{Words: 5, Characters: 9, Paragraphs: 1}},
+		{"한국어 문장입니다", Statistics{Words: 2, Characters: 8, Paragraphs: 1}},
+		{"Crème brûlée\nnaïve", Statistics{Words: 3, Characters: 16, Paragraphs: 1}},
+		{"dogs,cats", Statistics{Words: 2, Characters: 9, Paragraphs: 1}},
+		{"The end.Next one", Statistics{Words: 4, Characters: 14, Paragraphs: 1}},
+		{"1,000.50 and 3.", Statistics{Words: 3, Characters: 13, Paragraphs: 1}},
+		{"v2.x", Statistics{Words: 2, Characters: 4, Paragraphs: 1}},
+	}
+	for _, test := range tests {
+		actual := CountText(test.text)
+		if actual != test.expected {
+			t.Logf("CountText(%q) expected %+v but is %+v", test.text, test.expected, actual)
+			t.Fail()
+		}
+	}
+}
+
+func Test_reading_time(t *testing.T) {
+	statistics := Statistics{Words: 1000}
+	if actual := statistics.ReadingTime(200); actual != 5*time.Minute {
+		t.Logf("expected '5m0s' but is '%s'", actual)
+		t.Fail()
+	}
+	if actual := statistics.ReadingTime(0); actual != 4*time.Minute {
+		t.Logf("expected '4m0s' at the default speed but is '%s'", actual)
+		t.Fail()
+	}
+}
@@ -0,0 +1,253 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// ChapterText is the plain text of a spine document. Paragraphs and headings
+// are separated by a blank line, list items, table rows and <br> by a line
+// break.
+type ChapterText struct {
+	SpineIndex int
+	Item       ManifestItem
+	Href       string
+	Title      string
+	Text       string
+	Images     int
+	// Err is set if the document could not be read, Text is empty then.
+	Err      error
+	root     *html.Node
+	segments []textSegment
+}
+
+// textSegment maps a run of Text to the text node it was copied from.
+type textSegment struct {
+	start      int
+	length     int
+	node       *html.Node
+	nodeOffset int
+}
+
+var skippedElements = map[string]bool{
+	"head": true, "script": true, "style": true, "template": true, "rt": true, "rp": true,
+}
+
+var blockBreaks = map[string]string{
+	"address": "\n\n", "article": "\n\n", "aside": "\n\n", "blockquote": "\n\n", "body": "\n\n",
+	"dl": "\n\n", "div": "\n\n", "figcaption": "\n\n", "figure": "\n\n", "footer": "\n\n",
+	"h1": "\n\n", "h2": "\n\n", "h3": "\n\n", "h4": "\n\n", "h5": "\n\n", "h6": "\n\n",
+	"header": "\n\n", "hr": "\n\n", "main": "\n\n", "nav": "\n\n", "ol": "\n\n", "p": "\n\n",
+	"pre": "\n\n", "section": "\n\n", "table": "\n\n", "ul": "\n\n",
+	"br": "\n", "caption": "\n", "dd": "\n", "dt": "\n", "li": "\n", "tr": "\n",
+	"td": " ", "th": " ",
+}
+
+// ExtractText returns the plain text of every spine document in spine
+// order. Spine items that are not XHTML are replaced by the first XHTML item
+// of their fallback chain, or skipped if there is none. Documents that
+// cannot be read are returned without text and with Err set. Titles are
+// taken from the table of contents.
+func (book *Book) ExtractText() ([]ChapterText, error) {
+	toc, err := book.ReadTOC()
+	if err != nil {
+		return nil, err
+	}
+	var chapters []ChapterText
+	if book.Spine == nil {
+		return chapters, nil
+	}
+	for i := range *book.Spine {
+		item, err := book.spineDocument(i)
+		if err != nil {
+			continue
+		}
+		chapter, err := book.ExtractItemText(item)
+		if err != nil {
+			chapter = ChapterText{Item: item, Href: ResolveHref("", item.Href), Err: err}
+		}
+		chapter.SpineIndex = i
+		chapter.Title, _ = titleFor(toc, chapter.Href)
+		chapters = append(chapters, chapter)
+	}
+	return chapters, nil
+}
+
+// ExtractSpineText returns the plain text of the spine document at
+// spineIndex, without its title.
+func (book *Book) ExtractSpineText(spineIndex int) (ChapterText, error) {
+	item, err := book.spineDocument(spineIndex)
+	if err != nil {
+		return ChapterText{}, err
+	}
+	chapter, err := book.ExtractItemText(item)
+	chapter.SpineIndex = spineIndex
+	return chapter, err
+}
+
+// spineDocument returns the XHTML manifest item of a spine item, following
+// the fallback chain for other media types.
+func (book *Book) spineDocument(spineIndex int) (ManifestItem, error) {
+	if book.Spine == nil || spineIndex < 0 || spineIndex >= len(*book.Spine) {
+		return ManifestItem{}, fmt.Errorf("spine item %d does not exist", spineIndex)
+	}
+	idref := (*book.Spine)[spineIndex].IdRef
+	item, ok := book.ManifestItem(idref)
+	if !ok {
+		return ManifestItem{}, fmt.Errorf("manifest item %q does not exist", idref)
+	}
+	if item.IsXHTML() {
+		return item, nil
+	}
+	return book.ResolveFallback(item, []string{"application/xhtml+xml", "text/html"})
+}
+
+// ExtractItemText returns the plain text of an XHTML manifest item. Broken
+// markup is parsed like a browser would, entities are decoded and scripts and
+// styles are dropped.
+func (book *Book) ExtractItemText(item ManifestItem) (ChapterText, error) {
+	root, err := book.ReadItemHTML(item)
+	if err != nil {
+		return ChapterText{}, err
+	}
+	chapter := extractText(root)
+	chapter.SpineIndex = -1
+	chapter.Item = item
+	chapter.Href = ResolveHref("", item.Href)
+	return chapter, nil
+}
+
+type textExtractor struct {
+	builder      strings.Builder
+	segments     []textSegment
+	images       int
+	pendingSpace bool
+	pendingBreak string
+}
+
+func extractText(root *html.Node) ChapterText {
+	extractor := &textExtractor{}
+	extractor.walk(root, false)
+	return ChapterText{
+		Text:     extractor.builder.String(),
+		Images:   extractor.images,
+		root:     root,
+		segments: extractor.segments,
+	}
+}
+
+func (extractor *textExtractor) walk(node *html.Node, preformatted bool) {
+	switch node.Type {
+	case html.TextNode:
+		if preformatted {
+			extractor.writePreformatted(node)
+		} else {
+			extractor.writeText(node)
+		}
+		return
+	case html.ElementNode:
+		if skippedElements[node.Data] {
+			return
+		}
+		if node.Data == "img" || node.Data == "image" {
+			extractor.images++
+		}
+		extractor.separate(blockBreaks[node.Data])
+		preformatted = preformatted || node.Data == "pre"
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		extractor.walk(child, preformatted)
+	}
+	if node.Type == html.ElementNode {
+		extractor.separate(blockBreaks[node.Data])
+	}
+}
+
+func (extractor *textExtractor) separate(separator string) {
+	switch separator {
+	case " ":
+		extractor.pendingSpace = true
+	case "\n", "\n\n":
+		if len(separator) > len(extractor.pendingBreak) {
+			extractor.pendingBreak = separator
+		}
+	}
+}
+
+// flush writes the pending separator before the next word. Nothing is
+// written at the start of the text.
+func (extractor *textExtractor) flush() {
+	if extractor.builder.Len() > 0 {
+		if extractor.pendingBreak != "" {
+			extractor.builder.WriteString(extractor.pendingBreak)
+		} else if extractor.pendingSpace {
+			extractor.builder.WriteByte(' ')
+		}
+	}
+	extractor.pendingBreak = ""
+	extractor.pendingSpace = false
+}
+
+func (extractor *textExtractor) writeText(node *html.Node) {
+	data := node.Data
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRuneInString(data[i:])
+		if unicode.IsSpace(r) {
+			extractor.pendingSpace = true
+			i += size
+			continue
+		}
+		end := i
+		for end < len(data) {
+			r, size := utf8.DecodeRuneInString(data[end:])
+			if unicode.IsSpace(r) {
+				break
+			}
+			end += size
+		}
+		extractor.flush()
+		extractor.write(node, i, data[i:end])
+		i = end
+	}
+}
+
+func (extractor *textExtractor) writePreformatted(node *html.Node) {
+	if node.Data == "" {
+		return
+	}
+	extractor.flush()
+	extractor.write(node, 0, node.Data)
+}
+
+func (extractor *textExtractor) write(node *html.Node, nodeOffset int, text string) {
+	extractor.segments = append(extractor.segments, textSegment{
+		start:      extractor.builder.Len(),
+		length:     len(text),
+		node:       node,
+		nodeOffset: nodeOffset,
+	})
+	extractor.builder.WriteString(text)
+}
+
+// nodeAt returns the text node and the byte offset inside it of a byte
+// offset in Text. Offsets in separators map to the start of the next run.
+func (chapter ChapterText) nodeAt(offset int) (*html.Node, int, bool) {
+	i := sort.Search(len(chapter.segments), func(i int) bool {
+		segment := chapter.segments[i]
+		return segment.start+segment.length > offset
+	})
+	if i == len(chapter.segments) {
+		if i == 0 {
+			return nil, 0, false
+		}
+		last := chapter.segments[i-1]
+		return last.node, last.nodeOffset + last.length, true
+	}
+	segment := chapter.segments[i]
+	return segment.node, segment.nodeOffset + max(offset-segment.start, 0), true
+}
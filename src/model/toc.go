@@ -0,0 +1,129 @@
+package model
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+type TOCEntry struct {
+	Title    string
+	Href     string
+	Fragment string
+	Children []TOCEntry
+}
+
+type ncx struct {
+	NavMap ncxNavMap `xml:"navMap"`
+}
+
+type ncxNavMap struct {
+	NavPoints []ncxNavPoint `xml:"navPoint"`
+}
+
+type ncxNavPoint struct {
+	Label     string        `xml:"navLabel>text"`
+	Content   ncxContent    `xml:"content"`
+	NavPoints []ncxNavPoint `xml:"navPoint"`
+}
+
+type ncxContent struct {
+	Src string `xml:"src,attr"`
+}
+
+// ReadTOC reads the table of contents from the toc nav of the EPUB 3
+// navigation document, or from the NCX if the book has no navigation
+// document. Hrefs are relative to the package document.
+func (book *Book) ReadTOC() ([]TOCEntry, error) {
+	if nav, ok := book.ManifestItemByProperty("nav"); ok && book.exists(book.ItemPath(nav)) {
+		root, err := book.ReadItemHTML(nav)
+		if err != nil {
+			return nil, err
+		}
+		element := FindElement(root, func(node *html.Node) bool {
+			return node.Data == "nav" && HasToken(Attribute(node, "epub:type"), "toc")
+		})
+		if element != nil {
+			if list := FindElement(element, func(node *html.Node) bool { return node.Data == "ol" }); list != nil {
+				return navEntries(nav.Href, list), nil
+			}
+		}
+	}
+	item, ok := book.NCXItem()
+	if !ok || !book.exists(book.ItemPath(item)) {
+		return nil, nil
+	}
+	document := ncx{}
+	err := book.ReadXML(book.ItemPath(item), &document)
+	if err != nil {
+		return nil, err
+	}
+	return ncxEntries(item.Href, document.NavMap.NavPoints), nil
+}
+
+// NCXItem returns the manifest item of the EPUB 2 NCX.
+func (book *Book) NCXItem() (ManifestItem, bool) {
+	if book.Manifest != nil {
+		for _, item := range *book.Manifest {
+			if item.MediaType == "application/x-dtbncx+xml" {
+				return item, true
+			}
+		}
+	}
+	return ManifestItem{}, false
+}
+
+func navEntries(navHref string, list *html.Node) []TOCEntry {
+	var entries []TOCEntry
+	for item := list.FirstChild; item != nil; item = item.NextSibling {
+		if item.Type != html.ElementNode || item.Data != "li" {
+			continue
+		}
+		entry := TOCEntry{}
+		for child := item.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.Data {
+			case "a", "span":
+				entry.Title = textContent(child)
+				if href := Attribute(child, "href"); href != "" {
+					entry.Href = ResolveHref(navHref, href)
+					_, entry.Fragment, _ = strings.Cut(href, "#")
+				}
+			case "ol":
+				entry.Children = navEntries(navHref, child)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func ncxEntries(ncxHref string, navPoints []ncxNavPoint) []TOCEntry {
+	var entries []TOCEntry
+	for _, navPoint := range navPoints {
+		entry := TOCEntry{
+			Title:    strings.Join(strings.Fields(navPoint.Label), " "),
+			Href:     ResolveHref(ncxHref, navPoint.Content.Src),
+			Children: ncxEntries(ncxHref, navPoint.NavPoints),
+		}
+		_, entry.Fragment, _ = strings.Cut(navPoint.Content.Src, "#")
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// titleFor returns the title of the first entry, in document order, that
+// points to href.
+func titleFor(entries []TOCEntry, href string) (string, bool) {
+	for _, entry := range entries {
+		if entry.Href == href {
+			return entry.Title, true
+		}
+		if title, ok := titleFor(entry.Children, href); ok {
+			return title, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,165 @@
+package model
+
+import (
+	"archive/zip"
+	"hash/crc32"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	mimetypeFile  = "mimetype"
+	containerFile = "META-INF/container.xml"
+	epubMediaType = "application/epub+zip"
+)
+
+// WriteTo writes the book as EPUB container: the mimetype first, stored
+// uncompressed and without extra field, then META-INF/container.xml and all
+// other files. Unmodified files are copied without recompressing them,
+// files changed with WriteFile are compressed again.
+func (book *Book) WriteTo(writer io.Writer) (int64, error) {
+	counter := &countingWriter{writer: writer}
+	archive := zip.NewWriter(counter)
+	err := book.writeMimetype(archive)
+	if err != nil {
+		return counter.count, err
+	}
+	written := map[string]bool{mimetypeFile: true}
+	if book.exists(containerFile) {
+		if err := book.writeFile(archive, containerFile); err != nil {
+			return counter.count, err
+		}
+		written[containerFile] = true
+	}
+	for _, name := range book.fileNames() {
+		if written[name] {
+			continue
+		}
+		if err := book.writeFile(archive, name); err != nil {
+			return counter.count, err
+		}
+		written[name] = true
+	}
+	err = archive.Close()
+	return counter.count, err
+}
+
+// SaveAs writes the book to a new file, see WriteTo.
+func (book *Book) SaveAs(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	_, err = book.WriteTo(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// writeMimetype writes the mimetype entry with known sizes, so that no data
+// descriptor follows it.
+func (book *Book) writeMimetype(archive *zip.Writer) error {
+	data := []byte(epubMediaType)
+	if book.exists(mimetypeFile) {
+		var err error
+		data, err = book.ReadFile(mimetypeFile)
+		if err != nil {
+			return err
+		}
+	}
+	// Only the MS-DOS time is set, Modified would add an extra field.
+	date, clock := msDosTime(book.modifiedTime(mimetypeFile))
+	writer, err := archive.CreateRaw(&zip.FileHeader{
+		Name:               mimetypeFile,
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE(data),
+		CompressedSize64:   uint64(len(data)),
+		UncompressedSize64: uint64(len(data)),
+		ModifiedDate:       date,
+		ModifiedTime:       clock,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(data)
+	return err
+}
+
+func (book *Book) writeFile(archive *zip.Writer, name string) error {
+	original := book.zipFile(name)
+	data, modified := book.files[name]
+	if !modified {
+		return archive.Copy(original)
+	}
+	header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: book.modifiedTime(name)}
+	writer, err := archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(data)
+	return err
+}
+
+// modifiedTime returns the modification time of a file in the zip file, or
+// the current time for new files.
+func (book *Book) modifiedTime(name string) time.Time {
+	if original := book.zipFile(name); original != nil && !original.Modified.IsZero() {
+		return original.Modified
+	}
+	return time.Now()
+}
+
+func msDosTime(t time.Time) (uint16, uint16) {
+	if t.Year() < 1980 {
+		t = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	date := uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+	clock := uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	return date, clock
+}
+
+// fileNames returns the files of the zip file in their order, followed by
+// the files added with WriteFile.
+func (book *Book) fileNames() []string {
+	var names []string
+	seen := map[string]bool{}
+	if book.ZipReader != nil {
+		for _, file := range book.ZipReader.File {
+			if !seen[file.Name] {
+				names = append(names, file.Name)
+				seen[file.Name] = true
+			}
+		}
+	}
+	for _, name := range sortedKeys(book.files) {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (book *Book) zipFile(name string) *zip.File {
+	if book.ZipReader == nil {
+		return nil
+	}
+	for _, file := range book.ZipReader.File {
+		if file.Name == name {
+			return file
+		}
+	}
+	return nil
+}
+
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (writer *countingWriter) Write(data []byte) (int, error) {
+	n, err := writer.writer.Write(data)
+	writer.count += int64(n)
+	return n, err
+}
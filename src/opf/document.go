@@ -0,0 +1,484 @@
+package opf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	NamespaceOpf = "http://www.idpf.org/2007/opf"
+	NamespaceDC  = "http://purl.org/dc/elements/1.1/"
+	NamespaceXML = "http://www.w3.org/XML/1998/namespace"
+)
+
+// Document is a package document that is edited in place: elements of the
+// metadata can be removed and new markup inserted, everything else is kept
+// byte for byte.
+type Document struct {
+	data             []byte
+	Lang             string
+	Dir              string
+	UniqueIdentifier string
+	// Package is the package element, Spine the spine element; only their
+	// start tags are recorded.
+	Package Element
+	Spine   Element
+	// Metadata are the child elements of the metadata element.
+	Metadata    []Element
+	metadataEnd int
+	// Manifest are the child elements of the manifest element.
+	Manifest    []Element
+	manifestEnd int
+	// Itemrefs are the child elements of the spine element.
+	Itemrefs   []Element
+	sections   map[string]bool
+	packageEnd int
+	prefixes   map[string]string
+	ids        map[string]bool
+	indent     string
+	edits      []edit
+	removed    map[int]bool
+}
+
+type Element struct {
+	Name  xml.Name
+	Attr  []xml.Attr
+	Text  string
+	Start int
+	End   int
+}
+
+type edit struct {
+	start int
+	end   int
+	text  string
+}
+
+// Parse reads the package element, the namespaces it declares and the
+// children of its metadata element with their position in data.
+func Parse(data []byte) (*Document, error) {
+	document := &Document{
+		data:     data,
+		prefixes: map[string]string{"xml": NamespaceXML},
+		ids:      map[string]bool{},
+		removed:  map[int]bool{},
+		sections: map[string]bool{},
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	depth := 0
+	section := ""
+	var current *Element
+	for {
+		start := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			depth++
+			for _, attr := range token.Attr {
+				if attr.Name.Local == "id" {
+					document.ids[attr.Value] = true
+				}
+			}
+			switch {
+			case depth == 1:
+				document.declare(token.Attr)
+				document.Package = Element{Name: token.Name, Attr: token.Attr, Start: start}
+				document.Lang = attribute(token.Attr, NamespaceXML, "lang")
+				document.Dir = attribute(token.Attr, "", "dir")
+				document.UniqueIdentifier = attribute(token.Attr, "", "unique-identifier")
+			case depth == 2:
+				section = token.Name.Local
+				document.sections[section] = true
+				if section == "metadata" {
+					document.declare(token.Attr)
+				}
+				if section == "spine" {
+					document.Spine = Element{Name: token.Name, Attr: token.Attr, Start: start}
+				}
+			case depth == 3 && section == "metadata":
+				if document.indent == "" {
+					document.indent = indentation(data, start)
+				}
+				document.Metadata = append(document.Metadata, Element{Name: token.Name, Attr: token.Attr, Start: start})
+				current = &document.Metadata[len(document.Metadata)-1]
+			case depth == 3 && section == "manifest":
+				document.Manifest = append(document.Manifest, Element{Name: token.Name, Attr: token.Attr, Start: start})
+				current = &document.Manifest[len(document.Manifest)-1]
+			case depth == 3 && section == "spine":
+				document.Itemrefs = append(document.Itemrefs, Element{Name: token.Name, Attr: token.Attr, Start: start})
+				current = &document.Itemrefs[len(document.Itemrefs)-1]
+			}
+		case xml.CharData:
+			if current != nil {
+				current.Text += string(token)
+			}
+		case xml.EndElement:
+			switch {
+			case depth == 3 && current != nil:
+				current.End = int(decoder.InputOffset())
+				current.Text = strings.TrimSpace(current.Text)
+				current = nil
+			case depth == 2 && section == "metadata":
+				document.metadataEnd = start
+			case depth == 2 && section == "manifest":
+				document.manifestEnd = start
+			case depth == 1:
+				document.packageEnd = start
+			}
+			if depth == 2 {
+				section = ""
+			}
+			depth--
+		}
+	}
+	if document.metadataEnd == 0 {
+		return nil, errors.New("package document has no metadata element")
+	}
+	if document.indent == "" {
+		document.indent = "    "
+	}
+	return document, nil
+}
+
+func (document *Document) declare(attrs []xml.Attr) {
+	for _, attr := range attrs {
+		if attr.Name.Space == "xmlns" {
+			document.prefixes[attr.Name.Local] = attr.Value
+		}
+	}
+}
+
+// Prefix returns the prefix declared for a namespace.
+func (document *Document) Prefix(namespace string) (string, bool) {
+	for prefix, value := range document.prefixes {
+		if value == namespace {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+// QualifiedName returns the name of an element in the given namespace using
+// the prefix declared in the document. If the namespace is not declared,
+// fallbackPrefix is used and the declaration is returned as attributes for
+// Markup.
+func (document *Document) QualifiedName(namespace string, local string, fallbackPrefix string) (string, []string) {
+	if prefix, ok := document.Prefix(namespace); ok {
+		return prefix + ":" + local, nil
+	}
+	return fallbackPrefix + ":" + local, []string{"xmlns:" + fallbackPrefix, namespace}
+}
+
+// Replace removes the metadata elements matching match together with the
+// metas refining them and inserts markup in place of the first one. If no
+// element matches, markup is inserted after the last element of namespace,
+// or at the end of the metadata.
+func (document *Document) Replace(namespace string, match func(element Element) bool, markup []string) {
+	var matched []Element
+	ids := map[string]bool{}
+	for _, element := range document.Metadata {
+		if match(element) {
+			matched = append(matched, element)
+			if id := element.Attribute("", "id"); id != "" {
+				ids[id] = true
+			}
+		}
+	}
+	if len(matched) > 0 {
+		document.InsertBefore(matched[0], markup)
+	} else {
+		document.Add(namespace, markup)
+	}
+	for _, element := range matched {
+		document.Remove(element)
+	}
+	for _, element := range document.Metadata {
+		if element.Name.Local == "meta" && ids[element.Refines()] {
+			document.Remove(element)
+		}
+	}
+}
+
+// Add inserts markup after the last metadata element of namespace, or at the
+// end of the metadata.
+func (document *Document) Add(namespace string, markup []string) {
+	if last, ok := document.last(namespace); ok {
+		document.InsertAfter(last, markup)
+	} else {
+		document.Append(markup)
+	}
+}
+
+// Refinements returns the metas refining the element with the given id.
+func (document *Document) Refinements(id string) []Element {
+	var metas []Element
+	if id == "" {
+		return metas
+	}
+	for _, element := range document.Metadata {
+		if element.Name.Local == "meta" && element.Refines() == id {
+			metas = append(metas, element)
+		}
+	}
+	return metas
+}
+
+func (document *Document) last(namespace string) (Element, bool) {
+	for i := len(document.Metadata) - 1; i >= 0; i-- {
+		if document.Metadata[i].Name.Space == namespace && !document.removed[document.Metadata[i].Start] {
+			return document.Metadata[i], true
+		}
+	}
+	return Element{}, false
+}
+
+// HasId reports whether an element of the document has the given id.
+func (document *Document) HasId(id string) bool {
+	return document.ids[id]
+}
+
+// NewId returns an id with the given prefix that is not used in the
+// document yet.
+func (document *Document) NewId(prefix string) string {
+	for i := 1; ; i++ {
+		id := prefix + strconv.Itoa(i)
+		if !document.ids[id] {
+			document.ids[id] = true
+			return id
+		}
+	}
+}
+
+func (element Element) Attribute(space string, local string) string {
+	return attribute(element.Attr, space, local)
+}
+
+func (element Element) Is(space string, local string) bool {
+	return element.Name.Space == space && element.Name.Local == local
+}
+
+// Refines returns the id referenced by the refines attribute of a meta.
+func (element Element) Refines() string {
+	return strings.TrimPrefix(element.Attribute("", "refines"), "#")
+}
+
+// Remove removes an element together with the indentation in front of it.
+func (document *Document) Remove(element Element) {
+	if document.removed[element.Start] {
+		return
+	}
+	document.removed[element.Start] = true
+	document.edits = append(document.edits, edit{start: document.lineStart(element.Start), end: element.End})
+}
+
+// InsertBefore inserts elements, each on its own line, in front of element.
+func (document *Document) InsertBefore(element Element, markup []string) {
+	document.insert(document.lineStart(element.Start), markup)
+}
+
+// InsertAfter inserts elements, each on its own line, after element.
+func (document *Document) InsertAfter(element Element, markup []string) {
+	document.insert(element.End, markup)
+}
+
+// Append inserts elements at the end of the metadata element.
+func (document *Document) Append(markup []string) {
+	document.insert(document.lineStart(document.metadataEnd), markup)
+}
+
+// AppendManifest inserts elements at the end of the manifest element.
+func (document *Document) AppendManifest(markup []string) {
+	if document.manifestEnd == 0 {
+		return
+	}
+	document.insert(document.lineStart(document.manifestEnd), markup)
+}
+
+// HasSection reports whether the package element has a child element with
+// the given name, e.g. "guide".
+func (document *Document) HasSection(name string) bool {
+	return document.sections[name]
+}
+
+// AppendPackage inserts elements at the end of the package element, indented
+// like the spine. Lines of markup are indented, not the lines inside them.
+func (document *Document) AppendPackage(markup []string) {
+	if document.packageEnd == 0 {
+		return
+	}
+	indent := "  "
+	if document.Spine.Start > 0 {
+		indent = indentation(document.data, document.Spine.Start)
+	}
+	builder := strings.Builder{}
+	for _, line := range markup {
+		builder.WriteString("\n" + indent + line)
+	}
+	offset := document.lineStart(document.packageEnd)
+	document.edits = append(document.edits, edit{start: offset, end: offset, text: builder.String()})
+}
+
+// SetAttribute sets an attribute in the start tag of element. name is the
+// qualified name as written in the document, e.g. "opf:role".
+func (document *Document) SetAttribute(element Element, name string, value string) {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(value))
+	if start, end, ok := document.attributeValue(element, name); ok {
+		document.edits = append(document.edits, edit{start: start, end: end, text: escaped.String()})
+		return
+	}
+	end := document.tagEnd(element)
+	if end > 0 && document.data[end-1] == '/' {
+		end--
+	}
+	document.edits = append(document.edits, edit{start: end, end: end, text: " " + name + "=\"" + escaped.String() + "\""})
+}
+
+// SetText replaces the text of element. Empty elements are left unchanged.
+func (document *Document) SetText(element Element, text string) {
+	start := document.tagEnd(element) + 1
+	if start > element.End {
+		return
+	}
+	end := bytes.LastIndex(document.data[start:element.End], []byte("</"))
+	if end < 0 {
+		return
+	}
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(text))
+	document.edits = append(document.edits, edit{start: start, end: start + end, text: escaped.String()})
+}
+
+// RemoveAttribute removes an attribute from the start tag of element.
+func (document *Document) RemoveAttribute(element Element, name string) {
+	tag := document.data[element.Start:document.tagEnd(element)]
+	match := attributePattern(name).FindIndex(tag)
+	if match == nil {
+		return
+	}
+	document.edits = append(document.edits, edit{start: element.Start + match[0], end: element.Start + match[1]})
+}
+
+// attributeValue returns the position of the value of an attribute, without
+// the quotes.
+func (document *Document) attributeValue(element Element, name string) (int, int, bool) {
+	tag := document.data[element.Start:document.tagEnd(element)]
+	match := attributePattern(name).FindSubmatchIndex(tag)
+	if match == nil {
+		return 0, 0, false
+	}
+	return element.Start + match[2] + 1, element.Start + match[3] - 1, true
+}
+
+func (document *Document) tagEnd(element Element) int {
+	end := bytes.IndexByte(document.data[element.Start:], '>')
+	if end < 0 {
+		return len(document.data)
+	}
+	return element.Start + end
+}
+
+func attributePattern(name string) *regexp.Regexp {
+	return regexp.MustCompile(`\s+` + regexp.QuoteMeta(name) + `\s*=\s*("[^"]*"|'[^']*')`)
+}
+
+func (document *Document) insert(offset int, markup []string) {
+	builder := strings.Builder{}
+	for _, element := range markup {
+		builder.WriteString("\n" + document.indent + element)
+	}
+	document.edits = append(document.edits, edit{start: offset, end: offset, text: builder.String()})
+}
+
+// lineStart moves offset back over the indentation and line break in front
+// of it, so that removed elements do not leave empty lines.
+func (document *Document) lineStart(offset int) int {
+	line := bytes.LastIndexByte(document.data[:offset], '\n')
+	if line < 0 || strings.TrimSpace(string(document.data[line:offset])) != "" {
+		return offset
+	}
+	if line > 0 && document.data[line-1] == '\r' {
+		line--
+	}
+	return line
+}
+
+func indentation(data []byte, offset int) string {
+	line := bytes.LastIndexByte(data[:offset], '\n')
+	if line < 0 {
+		return ""
+	}
+	indent := string(data[line+1 : offset])
+	if strings.TrimSpace(indent) != "" {
+		return ""
+	}
+	return indent
+}
+
+// Bytes returns the document with all edits applied.
+func (document *Document) Bytes() []byte {
+	edits := append([]edit{}, document.edits...)
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].start != edits[j].start {
+			return edits[i].start < edits[j].start
+		}
+		// Insertions go in front of a removal at the same offset.
+		return edits[i].end == edits[i].start && edits[j].end != edits[j].start
+	})
+	output := bytes.Buffer{}
+	position := 0
+	for _, edit := range edits {
+		if edit.start < position {
+			continue
+		}
+		output.Write(document.data[position:edit.start])
+		output.WriteString(edit.text)
+		position = edit.end
+	}
+	output.Write(document.data[position:])
+	return output.Bytes()
+}
+
+func attribute(attrs []xml.Attr, space string, local string) string {
+	for _, attr := range attrs {
+		if attr.Name.Local == local && (attr.Name.Space == space || (space == NamespaceXML && attr.Name.Space == "xml")) {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// Markup serializes an element. attrs are name/value pairs, empty values are
+// left out.
+func Markup(name string, text string, attrs ...string) string {
+	builder := strings.Builder{}
+	builder.WriteString("<" + name)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if attrs[i+1] == "" {
+			continue
+		}
+		builder.WriteString(" " + attrs[i] + "=\"")
+		xml.EscapeText(&builder, []byte(attrs[i+1]))
+		builder.WriteString("\"")
+	}
+	if text == "" {
+		builder.WriteString("/>")
+		return builder.String()
+	}
+	builder.WriteString(">")
+	xml.EscapeText(&builder, []byte(text))
+	builder.WriteString("</" + name + ">")
+	return builder.String()
+}
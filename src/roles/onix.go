@@ -0,0 +1,117 @@
+package roles
+
+import "strings"
+
+type onixTable map[string]string
+
+func (table onixTable) Label(code string, _ string) (string, bool) {
+	label, ok := table[strings.ToUpper(code)]
+	return label, ok
+}
+
+var onixContributorRoles = onixTable{
+	"A01": "by (author)",
+	"A02": "with",
+	"A03": "screenplay by",
+	"A04": "libretto by",
+	"A05": "lyrics by",
+	"A06": "by (composer)",
+	"A07": "by (artist)",
+	"A08": "by (photographer)",
+	"A09": "created by",
+	"A10": "from an idea by",
+	"A11": "designed by",
+	"A12": "illustrated by",
+	"A13": "photographs by",
+	"A14": "text by",
+	"A15": "preface by",
+	"A16": "prologue by",
+	"A17": "summary by",
+	"A18": "supplement by",
+	"A19": "afterword by",
+	"A20": "notes by",
+	"A21": "commentaries by",
+	"A22": "epilogue by",
+	"A23": "foreword by",
+	"A24": "introduction by",
+	"A25": "footnotes by",
+	"A26": "memoir by",
+	"A27": "experiments by",
+	"A29": "introduction and notes by",
+	"A30": "software written by",
+	"A31": "book and lyrics by",
+	"A32": "contributions by",
+	"A33": "appendix by",
+	"A34": "index by",
+	"A35": "drawings by",
+	"A36": "cover design or artwork by",
+	"A37": "preliminary work by",
+	"A38": "original author",
+	"A39": "maps by",
+	"A40": "inked or colored by",
+	"A41": "paper engineering by",
+	"A42": "continued by",
+	"A43": "interviewer",
+	"A44": "interviewee",
+	"A45": "comic script by",
+	"A46": "inker",
+	"A47": "colorist",
+	"A48": "letterer",
+	"A51": "research by",
+	"A99": "other primary creator",
+	"B01": "edited by",
+	"B02": "revised by",
+	"B03": "retold by",
+	"B04": "abridged by",
+	"B05": "adapted by",
+	"B06": "translated by",
+	"B07": "as told by",
+	"B08": "translated with commentary by",
+	"B09": "series edited by",
+	"B10": "edited and translated by",
+	"B11": "editor-in-chief",
+	"B12": "guest editor",
+	"B13": "volume editor",
+	"B14": "editorial board member",
+	"B15": "editorial coordination by",
+	"B16": "managing editor",
+	"B17": "founded by",
+	"B18": "prepared for publication by",
+	"B19": "associate editor",
+	"B20": "consultant editor",
+	"B21": "general editor",
+	"B22": "dramatized by",
+	"B23": "general rapporteur",
+	"B24": "literary editor",
+	"B25": "arranged by (music)",
+	"B26": "technical editor",
+	"B99": "other adaptation by",
+	"C01": "compiled by",
+	"C02": "selected by",
+	"C03": "non-text material selected by",
+	"C04": "curated by",
+	"C99": "other compilation by",
+	"D01": "producer",
+	"D02": "director",
+	"D03": "conductor",
+	"D04": "choreographer",
+	"D99": "other direction by",
+	"E01": "actor",
+	"E02": "dancer",
+	"E03": "narrator",
+	"E04": "commentator",
+	"E05": "vocal soloist",
+	"E06": "instrumental soloist",
+	"E07": "read by",
+	"E08": "performed by (orchestra, band, ensemble)",
+	"E09": "speaker",
+	"E10": "presenter",
+	"E99": "performed by",
+	"F01": "filmed/photographed by",
+	"F02": "editor (film or video)",
+	"F99": "other recording by",
+	"Z01": "assisted by",
+	"Z02": "honored/dedicated to",
+	"Z98": "various roles",
+	"Z99": "other",
+}
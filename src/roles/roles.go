@@ -0,0 +1,84 @@
+package roles
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/mathieu-keller/epub-parser/model"
+)
+
+const (
+	SchemeMarcRelators = "marc:relators"
+	SchemeOnix         = "onix:codelist17"
+	Unknown            = "unknown"
+)
+
+type Scheme interface {
+	Label(code string, language string) (string, bool)
+}
+
+type Resolution struct {
+	Code   string
+	Scheme string
+	Label  string
+	Status model.RoleStatus
+}
+
+var (
+	schemesMutex sync.RWMutex
+	schemes      = map[string]Scheme{
+		SchemeMarcRelators: model.Relator,
+		SchemeOnix:         onixContributorRoles,
+	}
+)
+
+// Register adds or replaces the role vocabulary used for a scheme.
+func Register(scheme string, table Scheme) {
+	schemesMutex.Lock()
+	defer schemesMutex.Unlock()
+	schemes[strings.ToLower(scheme)] = table
+}
+
+// Resolve resolves a role code with English labels. An empty scheme is
+// treated as marc:relators, which is what EPUB 2 implies and EPUB 3
+// recommends.
+func Resolve(scheme string, code string) Resolution {
+	return ResolveIn(scheme, code, "en")
+}
+
+func ResolveIn(scheme string, code string, language string) Resolution {
+	code = strings.TrimSpace(code)
+	scheme = strings.ToLower(strings.TrimSpace(scheme))
+	if scheme == "" {
+		scheme = SchemeMarcRelators
+	}
+	resolution := Resolution{
+		Code:   code,
+		Scheme: scheme,
+		Status: model.RoleAbsent,
+	}
+	if code == "" {
+		return resolution
+	}
+	schemesMutex.RLock()
+	table, ok := schemes[scheme]
+	schemesMutex.RUnlock()
+	if ok {
+		if label, found := table.Label(code, language); found {
+			resolution.Label = label
+			resolution.Status = model.RoleResolved
+			return resolution
+		}
+	}
+	resolution.Label = Unknown
+	resolution.Status = model.RoleUnrecognized
+	return resolution
+}
+
+// Apply copies the resolution onto a creator.
+func (resolution Resolution) Apply(creator *model.Creator) {
+	creator.RawRole = resolution.Code
+	creator.RoleScheme = resolution.Scheme
+	creator.Role = resolution.Label
+	creator.RoleStatus = resolution.Status
+}
@@ -0,0 +1,41 @@
+package roles
+
+import (
+	"testing"
+
+	"github.com/mathieu-keller/epub-parser/model"
+)
+
+func Test_resolve(t *testing.T) {
+	tests := []struct {
+		scheme string
+		code   string
+		label  string
+		status model.RoleStatus
+	}{
+		{"", "", "", model.RoleAbsent},
+		{"marc:relators", "", "", model.RoleAbsent},
+		{"", "aut", "author", model.RoleResolved},
+		{"marc:relators", "ill", "illustrator", model.RoleResolved},
+		{"marc:relators", "xyz", Unknown, model.RoleUnrecognized},
+		{"onix:codelist17", "B06", "translated by", model.RoleResolved},
+		{"onix:codelist17", "b06", "translated by", model.RoleResolved},
+		{"onix:codelist17", "aut", Unknown, model.RoleUnrecognized},
+		{"custom:roles", "aut", Unknown, model.RoleUnrecognized},
+	}
+	for _, test := range tests {
+		resolution := Resolve(test.scheme, test.code)
+		if resolution.Label != test.label || resolution.Status != test.status {
+			t.Logf("Resolve(%q, %q) expected '%s'/'%s' but is '%s'/'%s'", test.scheme, test.code, test.label, test.status, resolution.Label, resolution.Status)
+			t.Fail()
+		}
+	}
+}
+
+func Test_resolve_localized(t *testing.T) {
+	resolution := ResolveIn("marc:relators", "aut", "de")
+	if resolution.Label != "Autor" {
+		t.Logf("expected 'Autor' but is '%s'", resolution.Label)
+		t.Fail()
+	}
+}
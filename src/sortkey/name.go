@@ -0,0 +1,134 @@
+package sortkey
+
+import (
+	"strings"
+	"unicode"
+)
+
+var particles = map[string]bool{
+	"van": true, "von": true, "de": true, "der": true, "den": true, "des": true,
+	"du": true, "da": true, "das": true, "di": true, "dos": true, "del": true,
+	"della": true, "la": true, "le": true, "ten": true, "ter": true, "zu": true,
+	"y": true, "'t": true, "d'": true, "bin": true, "ibn": true, "al": true,
+}
+
+var suffixes = map[string]bool{
+	"jr": true, "sr": true, "ii": true, "iii": true, "iv": true, "v": true,
+	"phd": true, "ph.d": true, "md": true, "m.d": true, "esq": true,
+}
+
+var corporateWords = map[string]bool{
+	"inc": true, "ltd": true, "llc": true, "gmbh": true, "ag": true, "co": true,
+	"corp": true, "corporation": true, "company": true, "press": true,
+	"publishing": true, "publishers": true, "books": true, "verlag": true,
+	"editions": true, "éditions": true, "editorial": true, "university": true,
+	"universität": true, "université": true, "universidad": true,
+	"society": true, "association": true, "institute": true, "foundation": true,
+	"group": true, "media": true, "studio": true, "studios": true, "team": true,
+	"library": true, "museum": true, "committee": true, "council": true,
+	"department": true, "ministry": true, "project": true, "collective": true,
+}
+
+// Name generates a "Last, First" sort key from a display name. Names that
+// are already inverted, consist of a single word or belong to an
+// organisation are returned unchanged.
+func Name(name string) string {
+	words := strings.Fields(name)
+	if len(words) < 2 || IsCorporate(name) {
+		return strings.Join(words, " ")
+	}
+	name = strings.Join(words, " ")
+	suffix := ""
+	if i := strings.LastIndex(name, ","); i >= 0 {
+		if !isSuffix(name[i+1:]) {
+			return name
+		}
+		if strings.Contains(name[:i], ",") {
+			return name
+		}
+		suffix = strings.TrimSpace(name[i+1:])
+		words = strings.Fields(name[:i])
+	} else if len(words) > 2 && isSuffix(words[len(words)-1]) {
+		suffix = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+	if len(words) < 2 {
+		return name
+	}
+
+	surnameStart := len(words) - 1
+	for surnameStart > 1 && isCapitalizedParticle(words[surnameStart-1]) {
+		surnameStart--
+	}
+	particleStart := surnameStart
+	for particleStart > 1 && isLowerParticle(words[particleStart-1]) {
+		particleStart--
+	}
+
+	sortKey := strings.Join(words[surnameStart:], " ")
+	given := strings.Join(words[:particleStart], " ")
+	particlesText := strings.Join(words[particleStart:surnameStart], " ")
+	sortKey += ", " + given
+	if particlesText != "" {
+		sortKey += " " + particlesText
+	}
+	if suffix != "" {
+		sortKey += ", " + suffix
+	}
+	return sortKey
+}
+
+// DisplayName turns an inverted name like "Doe, John" into "John Doe".
+func DisplayName(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	i := strings.Index(name, ",")
+	if i < 0 || IsCorporate(name) || isSuffix(name[i+1:]) {
+		return name
+	}
+	last := strings.TrimSpace(name[:i])
+	first := strings.TrimSpace(name[i+1:])
+	suffix := ""
+	if j := strings.LastIndex(first, ","); j >= 0 && isSuffix(first[j+1:]) {
+		suffix = strings.TrimSpace(first[j+1:])
+		first = strings.TrimSpace(first[:j])
+	}
+	if first == "" || last == "" {
+		return name
+	}
+	display := first + " " + last
+	if suffix != "" {
+		display += " " + suffix
+	}
+	return display
+}
+
+// IsCorporate reports whether a name looks like an organisation rather than
+// a person.
+func IsCorporate(name string) bool {
+	words := strings.Fields(name)
+	if len(words) > 1 && strings.EqualFold(words[0], "the") {
+		return true
+	}
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+			return unicode.IsPunct(r) && r != '&'
+		}))
+		if corporateWords[word] || word == "&" {
+			return true
+		}
+	}
+	return false
+}
+
+func isSuffix(word string) bool {
+	word = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(word), "."))
+	return suffixes[word]
+}
+
+func isLowerParticle(word string) bool {
+	return particles[word]
+}
+
+func isCapitalizedParticle(word string) bool {
+	return word != strings.ToLower(word) && particles[strings.ToLower(word)]
+}
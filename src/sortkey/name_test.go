@@ -0,0 +1,53 @@
+package sortkey
+
+import "testing"
+
+func Test_name(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"John Doe", "Doe, John"},
+		{"J. R. R. Tolkien", "Tolkien, J. R. R."},
+		{"Doe, John", "Doe, John"},
+		{"John, Doe", "John, Doe"},
+		{"Ludwig van Beethoven", "Beethoven, Ludwig van"},
+		{"Vincent van der Berg", "Berg, Vincent van der"},
+		{"Jean de La Fontaine", "La Fontaine, Jean de"},
+		{"Charles De Gaulle", "De Gaulle, Charles"},
+		{"Martin Luther King Jr.", "King, Martin Luther, Jr."},
+		{"John Smith, III", "Smith, John, III"},
+		{"King, Martin Luther, Jr.", "King, Martin Luther, Jr."},
+		{"GoLang", "GoLang"},
+		{"Penguin Random House Ltd.", "Penguin Random House Ltd."},
+		{"The Beatles", "The Beatles"},
+		{"Simon & Schuster", "Simon & Schuster"},
+		{"  John   Doe ", "Doe, John"},
+	}
+	for _, test := range tests {
+		actual := Name(test.name)
+		if actual != test.expected {
+			t.Logf("Name(%q) expected '%s' but is '%s'", test.name, test.expected, actual)
+			t.Fail()
+		}
+	}
+}
+
+func Test_display_name(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Doe, John", "John Doe"},
+		{"King, Martin Luther, Jr.", "Martin Luther King Jr."},
+		{"John Doe", "John Doe"},
+		{"John Smith, Jr.", "John Smith, Jr."},
+	}
+	for _, test := range tests {
+		actual := DisplayName(test.name)
+		if actual != test.expected {
+			t.Logf("DisplayName(%q) expected '%s' but is '%s'", test.name, test.expected, actual)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package sortkey
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/mathieu-keller/epub-parser/model"
+)
+
+var articles = map[string][]string{
+	"en": {"the", "a", "an"},
+	"de": {"der", "die", "das", "ein", "eine"},
+	"fr": {"le", "la", "les", "l'", "l’", "un", "une"},
+	"es": {"el", "la", "los", "las", "un", "una"},
+	"it": {"il", "lo", "la", "i", "gli", "le", "l'", "l’", "un", "una", "uno"},
+	"pt": {"o", "a", "os", "as", "um", "uma"},
+	"nl": {"de", "het", "een", "'t"},
+}
+
+// Title generates a sort key for a title by moving a leading article of the
+// given language to the end, e.g. "The Hobbit" becomes "Hobbit, The".
+func Title(title string, language string) string {
+	title = strings.TrimSpace(title)
+	for _, article := range articles[model.ParseLanguage(language).Base()] {
+		if len(title) <= len(article) || !strings.EqualFold(title[:len(article)], article) {
+			continue
+		}
+		rest := title[len(article):]
+		if strings.HasSuffix(article, "'") || strings.HasSuffix(article, "’") {
+			return strings.TrimSpace(rest) + ", " + title[:len(article)]
+		}
+		if !unicode.IsSpace(rune(rest[0])) {
+			continue
+		}
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			continue
+		}
+		return rest + ", " + title[:len(article)]
+	}
+	return title
+}
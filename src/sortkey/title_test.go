@@ -0,0 +1,32 @@
+package sortkey
+
+import "testing"
+
+func Test_title(t *testing.T) {
+	tests := []struct {
+		title    string
+		language string
+		expected string
+	}{
+		{"The Hobbit", "en", "Hobbit, The"},
+		{"the hobbit", "en-GB", "hobbit, the"},
+		{"A Tale of Two Cities", "en", "Tale of Two Cities, A"},
+		{"Theory of Everything", "en", "Theory of Everything"},
+		{"Der Steppenwolf", "de", "Steppenwolf, Der"},
+		{"Der Steppenwolf", "ger", "Steppenwolf, Der"},
+		{"Der Steppenwolf", "DE_de", "Steppenwolf, Der"},
+		{"Le Petit Prince", "fr", "Petit Prince, Le"},
+		{"L'Étranger", "fr", "Étranger, L'"},
+		{"El Aleph", "es", "Aleph, El"},
+		{"The Hobbit", "", "The Hobbit"},
+		{"The", "en", "The"},
+		{"Test epub", "en", "Test epub"},
+	}
+	for _, test := range tests {
+		actual := Title(test.title, test.language)
+		if actual != test.expected {
+			t.Logf("Title(%q, %q) expected '%s' but is '%s'", test.title, test.language, test.expected, actual)
+			t.Fail()
+		}
+	}
+}
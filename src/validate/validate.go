@@ -0,0 +1,549 @@
+package validate
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mathieu-keller/epub-parser/model"
+	"github.com/mathieu-keller/epub-parser/opf"
+)
+
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+)
+
+// Codes are grouped like the epubcheck message ids: PKG for the container,
+// OPF for the package document, RSC for resources and NAV for navigation.
+// The numbers are this package's own.
+const (
+	MimetypeMissing        = "PKG-001"
+	MimetypeNotFirst       = "PKG-002"
+	MimetypeContent        = "PKG-003"
+	MimetypeCompressed     = "PKG-004"
+	MimetypeExtraField     = "PKG-005"
+	ContainerMissing       = "PKG-006"
+	ContainerInvalid       = "PKG-007"
+	PackageMissing         = "PKG-008"
+	PackageInvalid         = "OPF-001"
+	VersionUnsupported     = "OPF-002"
+	IdentifierMissing      = "OPF-003"
+	TitleMissing           = "OPF-004"
+	LanguageMissing        = "OPF-005"
+	ModifiedMissing        = "OPF-006"
+	ModifiedInvalid        = "OPF-007"
+	UniqueIdentifierAbsent = "OPF-008"
+	UniqueIdentifierWrong  = "OPF-009"
+	DuplicateId            = "OPF-010"
+	ManifestItemInvalid    = "OPF-011"
+	SpineIdrefUnknown      = "OPF-012"
+	SpineEmpty             = "OPF-013"
+	ResourceMissing        = "RSC-001"
+	ResourceNotInManifest  = "RSC-002"
+	DuplicateContentId     = "RSC-003"
+	NavMissing             = "NAV-001"
+	NavMultiple            = "NAV-002"
+	NavTOCMissing          = "NAV-003"
+	NCXMissing             = "NAV-004"
+)
+
+const (
+	containerPath = "META-INF/container.xml"
+	mimetype      = "application/epub+zip"
+	packageType   = "application/oebps-package+xml"
+	ncxType       = "application/x-dtbncx+xml"
+)
+
+// modifiedFormat is the CCYY-MM-DDThh:mm:ssZ form EPUB 3 requires for
+// dcterms:modified.
+var modifiedFormat = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z$`)
+
+// Location is a file in the container and, if known, the line and column in
+// it.
+type Location struct {
+	Path   string `json:"path"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+func (location Location) String() string {
+	if location.Line == 0 {
+		return location.Path
+	}
+	return fmt.Sprintf("%s(%d,%d)", location.Path, location.Line, location.Column)
+}
+
+type Message struct {
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Location Location `json:"location"`
+	Text     string   `json:"text"`
+}
+
+func (message Message) String() string {
+	return fmt.Sprintf("%s(%s): %s: %s", strings.ToUpper(string(message.Severity)), message.Code, message.Location, message.Text)
+}
+
+type Report struct {
+	Messages []Message `json:"messages"`
+}
+
+// Valid reports whether there are no errors. Warnings do not make a book
+// invalid.
+func (report *Report) Valid() bool {
+	return report.Count(Error) == 0
+}
+
+func (report *Report) Count(severity Severity) int {
+	count := 0
+	for _, message := range report.Messages {
+		if message.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+func (report *Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(report, "", "  ")
+}
+
+func (report *Report) String() string {
+	builder := strings.Builder{}
+	for _, message := range report.Messages {
+		builder.WriteString(message.String() + "\n")
+	}
+	builder.WriteString(fmt.Sprintf("%d errors, %d warnings\n", report.Count(Error), report.Count(Warning)))
+	return builder.String()
+}
+
+// ValidateFile validates the EPUB file at filePath.
+func ValidateFile(filePath string) (*Report, error) {
+	reader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return Validate(&reader.Reader), nil
+}
+
+type validator struct {
+	reader      *zip.Reader
+	files       map[string]*zip.File
+	report      *Report
+	packagePath string
+	packageData []byte
+	document    *opf.Document
+	version     string
+	// items are the manifest items by id, paths their files by path.
+	items map[string]opf.Element
+	paths map[string]bool
+}
+
+// Validate checks the container, the package document and the files of an
+// EPUB. It reads the ZIP archive directly, so books that epub.OpenBook
+// rejects are reported on as well. Checks depending on a file that is missing
+// or cannot be parsed are skipped.
+func Validate(reader *zip.Reader) *Report {
+	validator := &validator{
+		reader: reader,
+		files:  map[string]*zip.File{},
+		report: &Report{},
+		items:  map[string]opf.Element{},
+		paths:  map[string]bool{},
+	}
+	for _, file := range reader.File {
+		validator.files[file.Name] = file
+	}
+	validator.mimetype()
+	if !validator.container() || !validator.packageDocument() {
+		return validator.report
+	}
+	validator.metadata()
+	validator.uniqueIdentifier()
+	validator.manifest()
+	validator.spine()
+	validator.navigation()
+	validator.unlistedFiles()
+	validator.duplicateIds(validator.packagePath, validator.packageData, DuplicateId)
+	validator.contentIds()
+	return validator.report
+}
+
+func (validator *validator) add(severity Severity, code string, location Location, format string, args ...any) {
+	validator.report.Messages = append(validator.report.Messages, Message{
+		Severity: severity,
+		Code:     code,
+		Location: location,
+		Text:     fmt.Sprintf(format, args...),
+	})
+}
+
+// at returns the location of a byte offset in the package document.
+func (validator *validator) at(offset int) Location {
+	return position(validator.packagePath, validator.packageData, offset)
+}
+
+func position(filePath string, data []byte, offset int) Location {
+	before := data[:min(offset, len(data))]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return Location{Path: filePath, Line: line, Column: column}
+}
+
+func (validator *validator) read(name string) ([]byte, error) {
+	file, ok := validator.files[name]
+	if !ok {
+		return nil, fmt.Errorf("file %s not found", name)
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// mimetype checks that the first entry is an uncompressed mimetype file
+// without extra field, so the media type can be read at a fixed offset.
+func (validator *validator) mimetype() {
+	location := Location{Path: "mimetype"}
+	file, ok := validator.files["mimetype"]
+	if !ok {
+		validator.add(Error, MimetypeMissing, location, "the mimetype file is missing")
+		return
+	}
+	if validator.reader.File[0] != file {
+		validator.add(Error, MimetypeNotFirst, location, "the mimetype file must be the first entry of the archive")
+	}
+	if file.Method != zip.Store {
+		validator.add(Error, MimetypeCompressed, location, "the mimetype file must not be compressed")
+	}
+	if len(file.Extra) > 0 {
+		validator.add(Error, MimetypeExtraField, location, "the mimetype entry must not have an extra field")
+	}
+	data, err := validator.read("mimetype")
+	if err != nil {
+		validator.add(Error, MimetypeContent, location, "the mimetype file cannot be read: %v", err)
+	} else if string(data) != mimetype {
+		validator.add(Error, MimetypeContent, location, "the mimetype file must contain exactly %q but contains %q", mimetype, data)
+	}
+}
+
+// container finds the package document through META-INF/container.xml.
+func (validator *validator) container() bool {
+	location := Location{Path: containerPath}
+	data, err := validator.read(containerPath)
+	if err != nil {
+		validator.add(Error, ContainerMissing, location, "%s is missing", containerPath)
+		return false
+	}
+	container := model.Container{}
+	if err := xml.Unmarshal(data, &container); err != nil {
+		validator.add(Error, ContainerInvalid, syntaxLocation(containerPath, err), "%s is not well-formed: %v", containerPath, err)
+		return false
+	}
+	rootfile := container.Rootfile
+	if rootfile.Path == "" {
+		validator.add(Error, ContainerInvalid, location, "no rootfile with a full-path attribute")
+		return false
+	}
+	if rootfile.Type != packageType {
+		validator.add(Error, ContainerInvalid, location, "rootfile %s has media type %q instead of %q", rootfile.Path, rootfile.Type, packageType)
+	}
+	validator.packagePath = rootfile.Path
+	validator.packageData, err = validator.read(rootfile.Path)
+	if err != nil {
+		validator.add(Error, PackageMissing, location, "package document %s is missing", rootfile.Path)
+		return false
+	}
+	return true
+}
+
+func (validator *validator) packageDocument() bool {
+	document, err := opf.Parse(validator.packageData)
+	if err != nil {
+		validator.add(Error, PackageInvalid, syntaxLocation(validator.packagePath, err), "package document is not valid: %v", err)
+		return false
+	}
+	validator.document = document
+	validator.version = document.Package.Attribute("", "version")
+	if !strings.HasPrefix(validator.version, "2.") && !strings.HasPrefix(validator.version, "3.") {
+		validator.add(Error, VersionUnsupported, validator.at(document.Package.Start), "unsupported package version %q", validator.version)
+	}
+	return true
+}
+
+func syntaxLocation(filePath string, err error) Location {
+	syntaxError := &xml.SyntaxError{}
+	if errors.As(err, &syntaxError) {
+		return Location{Path: filePath, Line: syntaxError.Line}
+	}
+	return Location{Path: filePath}
+}
+
+func (validator *validator) isVersion3() bool {
+	return strings.HasPrefix(validator.version, "3.")
+}
+
+// metadata checks for the required identifier, title and language and, in
+// EPUB 3, a single well-formed dcterms:modified.
+func (validator *validator) metadata() {
+	counts := map[string]int{}
+	var modified []opf.Element
+	for _, element := range validator.document.Metadata {
+		if element.Name.Space == opf.NamespaceDC && strings.TrimSpace(element.Text) != "" {
+			counts[element.Name.Local]++
+		}
+		if element.Name.Local == "meta" && element.Attribute("", "property") == "dcterms:modified" && element.Refines() == "" {
+			modified = append(modified, element)
+		}
+	}
+	location := validator.at(validator.document.Package.Start)
+	if counts["identifier"] == 0 {
+		validator.add(Error, IdentifierMissing, location, "metadata has no dc:identifier")
+	}
+	if counts["title"] == 0 {
+		validator.add(Error, TitleMissing, location, "metadata has no dc:title")
+	}
+	if counts["language"] == 0 {
+		validator.add(Error, LanguageMissing, location, "metadata has no dc:language")
+	}
+	if !validator.isVersion3() {
+		return
+	}
+	switch {
+	case len(modified) == 0:
+		validator.add(Error, ModifiedMissing, location, "metadata has no dcterms:modified meta")
+	case len(modified) > 1:
+		validator.add(Error, ModifiedInvalid, validator.at(modified[1].Start), "metadata has more than one dcterms:modified meta")
+	case !modifiedFormat.MatchString(modified[0].Text):
+		validator.add(Error, ModifiedInvalid, validator.at(modified[0].Start), "dcterms:modified %q is not of the form CCYY-MM-DDThh:mm:ssZ", modified[0].Text)
+	}
+}
+
+// uniqueIdentifier checks that the unique-identifier attribute references a
+// dc:identifier.
+func (validator *validator) uniqueIdentifier() {
+	document := validator.document
+	location := validator.at(document.Package.Start)
+	if document.UniqueIdentifier == "" {
+		validator.add(Error, UniqueIdentifierAbsent, location, "package element has no unique-identifier attribute")
+		return
+	}
+	for _, element := range document.Metadata {
+		if element.Attribute("", "id") == document.UniqueIdentifier {
+			if !element.Is(opf.NamespaceDC, "identifier") {
+				validator.add(Error, UniqueIdentifierWrong, validator.at(element.Start), "unique-identifier %q references a %s element, not a dc:identifier", document.UniqueIdentifier, element.Name.Local)
+			}
+			return
+		}
+	}
+	validator.add(Error, UniqueIdentifierWrong, location, "unique-identifier %q does not reference a dc:identifier", document.UniqueIdentifier)
+}
+
+// itemPath returns the path of a manifest href in the container, or false for
+// remote resources.
+func (validator *validator) itemPath(href string) (string, bool) {
+	if strings.Contains(href, "://") || strings.HasPrefix(href, "data:") {
+		return "", false
+	}
+	return path.Join(path.Dir(validator.packagePath), model.ResolveHref("", href)), true
+}
+
+// manifest checks that items have an id, href and media type, and that their
+// files exist.
+func (validator *validator) manifest() {
+	for _, element := range validator.document.Manifest {
+		if element.Name.Local != "item" {
+			continue
+		}
+		location := validator.at(element.Start)
+		id := element.Attribute("", "id")
+		href := element.Attribute("", "href")
+		for _, attr := range []string{"id", "href", "media-type"} {
+			if element.Attribute("", attr) == "" {
+				validator.add(Error, ManifestItemInvalid, location, "manifest item has no %s attribute", attr)
+			}
+		}
+		if id != "" {
+			validator.items[id] = element
+		}
+		if href == "" {
+			continue
+		}
+		itemPath, local := validator.itemPath(href)
+		if !local {
+			continue
+		}
+		validator.paths[itemPath] = true
+		if _, ok := validator.files[itemPath]; !ok {
+			validator.add(Error, ResourceMissing, location, "manifest item %q references %s, which is not in the container", id, itemPath)
+		}
+	}
+}
+
+// spine checks that the spine is not empty and references manifest items.
+func (validator *validator) spine() {
+	document := validator.document
+	count := 0
+	for _, element := range document.Itemrefs {
+		if element.Name.Local != "itemref" {
+			continue
+		}
+		count++
+		idref := element.Attribute("", "idref")
+		if _, ok := validator.items[idref]; !ok {
+			validator.add(Error, SpineIdrefUnknown, validator.at(element.Start), "itemref %q does not reference a manifest item", idref)
+		}
+	}
+	if count == 0 {
+		validator.add(Error, SpineEmpty, validator.at(document.Spine.Start), "spine has no itemref")
+	}
+}
+
+// navigation checks for exactly one navigation document with a toc nav in
+// EPUB 3 and for the NCX referenced by the spine in EPUB 2.
+func (validator *validator) navigation() {
+	if !validator.isVersion3() {
+		validator.ncx()
+		return
+	}
+	var navs []opf.Element
+	for _, element := range validator.document.Manifest {
+		if slices.Contains(strings.Fields(element.Attribute("", "properties")), "nav") {
+			navs = append(navs, element)
+		}
+	}
+	if len(navs) == 0 {
+		validator.add(Error, NavMissing, validator.at(validator.document.Package.Start), "manifest has no item with the nav property")
+		return
+	}
+	if len(navs) > 1 {
+		validator.add(Error, NavMultiple, validator.at(navs[1].Start), "manifest has more than one item with the nav property")
+	}
+	navPath, local := validator.itemPath(navs[0].Attribute("", "href"))
+	if !local {
+		return
+	}
+	data, err := validator.read(navPath)
+	if err != nil {
+		return
+	}
+	if !hasTOCNav(data) {
+		validator.add(Error, NavTOCMissing, Location{Path: navPath}, "navigation document has no nav element with epub:type toc")
+	}
+}
+
+func (validator *validator) ncx() {
+	spine := validator.document.Spine
+	toc := spine.Attribute("", "toc")
+	if toc == "" {
+		validator.add(Error, NCXMissing, validator.at(spine.Start), "spine has no toc attribute referencing the NCX")
+		return
+	}
+	item, ok := validator.items[toc]
+	if !ok {
+		validator.add(Error, NCXMissing, validator.at(spine.Start), "spine toc %q does not reference a manifest item", toc)
+	} else if mediaType := item.Attribute("", "media-type"); mediaType != ncxType {
+		validator.add(Error, NCXMissing, validator.at(item.Start), "spine toc %q references an item of media type %q instead of %q", toc, mediaType, ncxType)
+	}
+}
+
+func hasTOCNav(data []byte) bool {
+	decoder := newContentDecoder(data)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "nav" {
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "type" && slices.Contains(strings.Fields(attr.Value), "toc") {
+					return true
+				}
+			}
+		}
+	}
+}
+
+// unlistedFiles warns about files that are neither container files nor in
+// the manifest.
+func (validator *validator) unlistedFiles() {
+	var names []string
+	for name, file := range validator.files {
+		if file.FileInfo().IsDir() || name == "mimetype" || strings.HasPrefix(name, "META-INF/") || name == validator.packagePath {
+			continue
+		}
+		if !validator.paths[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		validator.add(Warning, ResourceNotInManifest, Location{Path: name}, "file is not declared in the manifest")
+	}
+}
+
+// duplicateIds reports id attributes used more than once in a document.
+func (validator *validator) duplicateIds(filePath string, data []byte, code string) {
+	decoder := newContentDecoder(data)
+	seen := map[string]bool{}
+	for {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err != nil {
+			return
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		for _, attr := range start.Attr {
+			if attr.Name.Local != "id" || (attr.Name.Space != "" && attr.Name.Space != opf.NamespaceXML) {
+				continue
+			}
+			if seen[attr.Value] {
+				validator.add(Error, code, position(filePath, data, offset), "duplicate id %q", attr.Value)
+			}
+			seen[attr.Value] = true
+		}
+	}
+}
+
+// contentIds checks the XHTML documents of the manifest for duplicate ids.
+func (validator *validator) contentIds() {
+	for _, element := range validator.document.Manifest {
+		if element.Attribute("", "media-type") != "application/xhtml+xml" {
+			continue
+		}
+		itemPath, local := validator.itemPath(element.Attribute("", "href"))
+		if !local {
+			continue
+		}
+		if data, err := validator.read(itemPath); err == nil {
+			validator.duplicateIds(itemPath, data, DuplicateContentId)
+		}
+	}
+}
+
+// newContentDecoder returns a decoder that accepts HTML entities, as content
+// documents often use them.
+func newContentDecoder(data []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	return decoder
+}
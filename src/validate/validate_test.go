@@ -0,0 +1,193 @@
+package validate
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mathieu-keller/epub-parser/builder"
+	"github.com/mathieu-keller/epub-parser/internal/epubtest"
+	"github.com/mathieu-keller/epub-parser/model"
+)
+
+const container = `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>`
+
+const validPackage = `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:title>Valid</dc:title>
+    <dc:language>en</dc:language>
+    <meta property="dcterms:modified">2024-01-02T03:04:05Z</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="c1" href="chapter%201.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="c1"/>
+  </spine>
+</package>`
+
+const nav = `<?xml version="1.0" encoding="utf-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<body><nav epub:type="toc"><ol><li><a href="chapter%201.xhtml">One</a></li></ol></nav></body>
+</html>`
+
+const invalidPackage = `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="missing">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="id">urn:uuid:1</dc:identifier>
+    <dc:language>en</dc:language>
+    <meta property="dcterms:modified">2024-01-02</meta>
+  </metadata>
+  <manifest>
+    <item id="c1" href="chapter%201.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c1" href="missing.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="c1"/>
+    <itemref idref="c2"/>
+  </spine>
+</package>`
+
+func validFiles() []epubtest.Entry {
+	return []epubtest.Entry{
+		{Name: "mimetype", Content: "application/epub+zip"},
+		{Name: "META-INF/container.xml", Content: container, Method: zip.Deflate},
+		{Name: "OEBPS/content.opf", Content: validPackage, Method: zip.Deflate},
+		{Name: "OEBPS/nav.xhtml", Content: nav, Method: zip.Deflate},
+		{Name: "OEBPS/chapter 1.xhtml", Content: `<html xmlns="http://www.w3.org/1999/xhtml"><body><p id="a">&nbsp;</p></body></html>`, Method: zip.Deflate},
+	}
+}
+
+func Test_valid(t *testing.T) {
+	report := Validate(epubtest.ZipEntries(t, validFiles()))
+	if !report.Valid() || len(report.Messages) != 0 {
+		t.Logf("expected no messages but got\n%s", report)
+		t.Fail()
+	}
+}
+
+func Test_invalid_package(t *testing.T) {
+	files := validFiles()
+	files[2].Content = invalidPackage
+	files[4].Content = `<html xmlns="http://www.w3.org/1999/xhtml"><body><p id="a">1</p><p id="a">2</p></body></html>`
+	files = append(files, epubtest.Entry{Name: "OEBPS/extra.css", Content: "p {}"})
+	report := Validate(epubtest.ZipEntries(t, files))
+	assertCodes(t, report, []string{
+		TitleMissing,
+		ModifiedInvalid,
+		UniqueIdentifierWrong,
+		ResourceMissing,
+		SpineIdrefUnknown,
+		NavMissing,
+		ResourceNotInManifest,
+		DuplicateId,
+		DuplicateContentId,
+	})
+	for _, message := range report.Messages {
+		if message.Code == SpineIdrefUnknown && message.Location.String() != "OEBPS/content.opf(14,5)" {
+			t.Logf("expected location OEBPS/content.opf(14,5) but got %s", message.Location)
+			t.Fail()
+		}
+		if message.Code == ResourceNotInManifest && message.Severity != Warning {
+			t.Logf("expected %s to be a warning", message.Code)
+			t.Fail()
+		}
+	}
+	if report.Valid() {
+		t.Log("report should not be valid")
+		t.Fail()
+	}
+	data, err := report.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := Report{}
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Messages) != len(report.Messages) {
+		t.Logf("json round trip failed: %v", err)
+		t.Fail()
+	}
+}
+
+func Test_mimetype(t *testing.T) {
+	files := validFiles()
+	files[0], files[1] = files[1], files[0]
+	files[1].Method = zip.Deflate
+	files[1].Content = "application/epub+zip\n"
+	assertCodes(t, Validate(epubtest.ZipEntries(t, files)), []string{MimetypeNotFirst, MimetypeCompressed, MimetypeContent})
+}
+
+func Test_missing_container(t *testing.T) {
+	files := validFiles()
+	files = append(files[:1], files[2:]...)
+	report := Validate(epubtest.ZipEntries(t, files))
+	assertCodes(t, report, []string{ContainerMissing})
+}
+
+func Test_epub_2_without_ncx(t *testing.T) {
+	files := validFiles()
+	files[2].Content = strings.Replace(validPackage, `version="3.0"`, `version="2.0"`, 1)
+	assertCodes(t, Validate(epubtest.ZipEntries(t, files)), []string{NCXMissing})
+}
+
+func Test_test_books(t *testing.T) {
+	// The test books only have metadata, their manifest and spine are empty.
+	expected := map[string][]string{
+		"../test_epub_v2.0.epub": {MimetypeNotFirst, SpineEmpty, NCXMissing},
+		"../test_epub_v3.0.epub": {SpineEmpty, NavMissing},
+	}
+	for name, codes := range expected {
+		report, err := ValidateFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertCodes(t, report, codes)
+	}
+}
+
+func Test_built_book(t *testing.T) {
+	bookBuilder := builder.New(model.Metadata{
+		Titles:    &[]model.Title{{Title: "Built"}},
+		Languages: &[]model.Language{model.ParseLanguage("en")},
+	})
+	bookBuilder.NCX = true
+	if _, err := bookBuilder.AddChapter("chapter.xhtml", "Chapter", []byte(`<html xmlns="http://www.w3.org/1999/xhtml"><body/></html>`)); err != nil {
+		t.Fatal(err)
+	}
+	book, err := bookBuilder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCodes(t, Validate(epubtest.Write(t, book)), nil)
+}
+
+// assertCodes checks that the report has a message of each code and no
+// others.
+func assertCodes(t *testing.T, report *Report, codes []string) {
+	expected := map[string]bool{}
+	for _, code := range codes {
+		expected[code] = true
+	}
+	found := map[string]bool{}
+	for _, message := range report.Messages {
+		found[message.Code] = true
+		if !expected[message.Code] {
+			t.Logf("unexpected message %s", message)
+			t.Fail()
+		}
+	}
+	for _, code := range codes {
+		if !found[code] {
+			t.Logf("expected message %s but got\n%s", code, report)
+			t.Fail()
+		}
+	}
+}
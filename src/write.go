@@ -0,0 +1,35 @@
+package epub
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mathieu-keller/epub-parser/epub_v2"
+	"github.com/mathieu-keller/epub-parser/epub_v3"
+	"github.com/mathieu-keller/epub-parser/model"
+)
+
+// SaveMetadata writes book.Metadata back into the package document of the
+// book. The package document is replaced in memory, see model.Book.WriteFile.
+// For EPUB 3 dcterms:modified is set to the current time.
+func SaveMetadata(book *model.Book) error {
+	ebookVersion, err := strconv.ParseFloat(book.Version, 64)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	switch {
+	case ebookVersion >= 3.0 && ebookVersion < 4.0:
+		data, err = epub_v3.WriteOpf(book, time.Now())
+	case ebookVersion >= 2.0 && ebookVersion < 3.0:
+		data, err = epub_v2.WriteOpf(book)
+	default:
+		return fmt.Errorf("%f not supported yet!", ebookVersion)
+	}
+	if err != nil {
+		return err
+	}
+	book.WriteFile(book.Container.Rootfile.Path, data)
+	return nil
+}